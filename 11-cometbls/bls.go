@@ -0,0 +1,75 @@
+package cometbls
+
+import (
+	"context"
+
+	errorsmod "cosmossdk.io/errors"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+)
+
+// CometblsSigDST is the domain separation tag CometBLS validators hash their vote sign bytes to G2 with.
+const CometblsSigDST = "BN254G2_XMD:MiMC-256_SVDW_RO_"
+
+// VerifyAggregateSignature aggregates the compressed G1 public keys and verifies the compressed G2
+// aggregate signature of the message against it, the message being hashed to G2 with CometblsSigDST
+// the way CometBLS validators sign, see hashToG2.
+// The verification is traced as a child span of the context, see WithTracer.
+//
+// As all the signatures are over the same message, the aggregation is only safe against rogue key
+// attacks if the caller made sure every public key comes with a proof of possession of its secret key.
+func VerifyAggregateSignature(ctx context.Context, pubkeys [][]byte, message []byte, aggSig []byte) (err error) {
+	_, span := getTracer(ctx).Start(ctx, SpanVerifyAggregateSignature)
+	span.SetAttribute(AttributeSigners, int64(len(pubkeys)))
+	defer func() { endSpan(span, err) }()
+
+	if len(pubkeys) == 0 {
+		return ErrEmptyPublicKeys
+	}
+
+	var aggPubKey curve.G1Affine
+	for i, pubkey := range pubkeys {
+		if len(pubkey) != curve.SizeOfG1AffineCompressed {
+			return errorsmod.Wrapf(ErrInvalidPublicKey, "public key %d must be %d bytes, got: %d", i, curve.SizeOfG1AffineCompressed, len(pubkey))
+		}
+
+		var pk curve.G1Affine
+		if _, err := pk.SetBytes(pubkey); err != nil {
+			return errorsmod.Wrapf(ErrInvalidPublicKey, "public key %d: %s", i, err)
+		}
+		if pk.IsInfinity() {
+			return errorsmod.Wrapf(ErrInvalidPublicKey, "public key %d cannot be the point at infinity", i)
+		}
+
+		aggPubKey.Add(&aggPubKey, &pk)
+	}
+
+	if len(aggSig) != curve.SizeOfG2AffineCompressed {
+		return errorsmod.Wrapf(ErrInvalidSignature, "signature must be %d bytes, got: %d", curve.SizeOfG2AffineCompressed, len(aggSig))
+	}
+
+	var sig curve.G2Affine
+	if _, err := sig.SetBytes(aggSig); err != nil {
+		return errorsmod.Wrap(ErrInvalidSignature, err.Error())
+	}
+
+	hashedMessage, err := hashToG2(message)
+	if err != nil {
+		return err
+	}
+
+	_, _, g1Gen, _ := curve.Generators()
+	var negG1Gen curve.G1Affine
+	negG1Gen.Neg(&g1Gen)
+
+	// e(aggPubKey, H(m)) == e(g1, aggSig)
+	ok, err := curve.PairingCheck([]curve.G1Affine{aggPubKey, negG1Gen}, []curve.G2Affine{hashedMessage, sig})
+	if err != nil {
+		return errorsmod.Wrap(ErrSignatureVerification, err.Error())
+	}
+	if !ok {
+		return ErrSignatureVerification
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package cometbls
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAggregateSignature signs the message with the deterministic secret keys 1..n and returns the
+// compressed public keys along with the compressed aggregate signature.
+func newTestAggregateSignature(t *testing.T, n int, message []byte) ([][]byte, []byte) {
+	t.Helper()
+
+	_, _, g1Gen, _ := curve.Generators()
+	hashedMessage, err := hashToG2(message)
+	require.NoError(t, err)
+
+	var (
+		pubkeys [][]byte
+		aggSig  curve.G2Affine
+	)
+	for i := 1; i <= n; i++ {
+		sk := big.NewInt(int64(i))
+
+		var pk curve.G1Affine
+		pk.ScalarMultiplication(&g1Gen, sk)
+		pkBytes := pk.Bytes()
+		pubkeys = append(pubkeys, pkBytes[:])
+
+		var sig curve.G2Affine
+		sig.ScalarMultiplication(&hashedMessage, sk)
+		aggSig.Add(&aggSig, &sig)
+	}
+
+	aggSigBytes := aggSig.Bytes()
+	return pubkeys, aggSigBytes[:]
+}
+
+func TestVerifyAggregateSignature(t *testing.T) {
+	message := []byte("cometbls")
+	pubkeys, aggSig := newTestAggregateSignature(t, 3, message)
+
+	testCases := []struct {
+		name    string
+		pubkeys [][]byte
+		message []byte
+		aggSig  []byte
+		expErr  error
+	}{
+		{"valid aggregate signature", pubkeys, message, aggSig, nil},
+		{"tampered message", pubkeys, []byte("tampered"), aggSig, ErrSignatureVerification},
+		{"message not made of field elements", pubkeys, make([]byte, 33), aggSig, ErrSignatureVerification},
+		{"missing public key", pubkeys[:2], message, aggSig, ErrSignatureVerification},
+		{"empty public keys", nil, message, aggSig, ErrEmptyPublicKeys},
+		{"public key with invalid length", [][]byte{pubkeys[0][:31]}, message, aggSig, ErrInvalidPublicKey},
+		{"signature with invalid length", pubkeys, message, aggSig[:63], ErrInvalidSignature},
+		{"malformed signature", pubkeys, message, make([]byte, len(aggSig)), ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyAggregateSignature(context.Background(), tc.pubkeys, tc.message, tc.aggSig)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
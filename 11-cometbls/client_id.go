@@ -0,0 +1,25 @@
+package cometbls
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// SelfClientID is the client ID reported when none has been attached to the context.
+const SelfClientID = "self"
+
+type clientIDKey struct{}
+
+// WithClientID returns a copy of the context carrying the client ID, used to scope the errors returned by
+// the client and consensus host methods whose signatures do not include it.
+func WithClientID(ctx sdk.Context, clientID string) sdk.Context {
+	return ctx.WithValue(clientIDKey{}, clientID)
+}
+
+// GetClientID returns the client ID attached to the context, or SelfClientID if there is none.
+func GetClientID(ctx sdk.Context) string {
+	clientID, ok := ctx.Value(clientIDKey{}).(string)
+	if !ok || clientID == "" {
+		return SelfClientID
+	}
+	return clientID
+}
@@ -0,0 +1,28 @@
+package cometbls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIDScopedErrors(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	require.Equal(t, SelfClientID, GetClientID(ctx))
+
+	clientState := newTestClientState()
+	clientState.FrozenHeight = FrozenHeight
+	host := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200})
+
+	err := host.ValidateSelfClient(ctx, clientState)
+	require.ErrorContains(t, err, "client self")
+
+	ctx = WithClientID(ctx, "11-cometbls-0")
+	require.Equal(t, "11-cometbls-0", GetClientID(ctx))
+
+	err = host.ValidateSelfClient(ctx, clientState)
+	require.ErrorContains(t, err, "client 11-cometbls-0")
+
+	err = clientState.VerifyClientMessage(ctx, newTestCodec(), newTestClientStore(), newTestHeader(6, 5, ctx.BlockTime()))
+	require.ErrorContains(t, err, "client 11-cometbls-0")
+}
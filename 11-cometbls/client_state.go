@@ -1,7 +1,10 @@
 package cometbls
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"strings"
+	"time"
 
 	ics23 "github.com/cosmos/ics23/go"
 
@@ -9,18 +12,23 @@ import (
 	storetypes "cosmossdk.io/store/types"
 
 	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	tmtypes "github.com/cometbft/cometbft/types"
 
 	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
 	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	host "github.com/cosmos/ibc-go/v8/modules/core/24-host"
 	ibcerrors "github.com/cosmos/ibc-go/v8/modules/core/errors"
 	"github.com/cosmos/ibc-go/v8/modules/core/exported"
 )
 
 var _ exported.ClientState = (*ClientState)(nil)
 
+// DefaultMaxClockDrift is the max clock drift of clients created from a checkpoint.
+const DefaultMaxClockDrift = 10 * time.Second
+
 // NewClientState creates a new ClientState instance
 func NewClientState(
 	chainID string,
@@ -37,11 +45,126 @@ func NewClientState(
 	}
 }
 
+// DefaultClientState creates a new ClientState from the parameters of the counterparty chain, as relayers do when
+// setting up a client. A zero max clock drift defaults to DefaultMaxClockDrift. The client is not frozen and verifies
+// proofs against GetProofSpecs. The resulting client state must pass ValidateBasic.
+func DefaultClientState(
+	chainID string,
+	latestHeight clienttypes.Height,
+	unbonding, trusting, maxClockDrift time.Duration,
+) (*ClientState, error) {
+	if unbonding <= 0 {
+		return nil, errorsmod.Wrap(ErrInvalidUnbondingPeriod, "unbonding period must be greater than zero")
+	}
+	if trusting <= 0 {
+		return nil, errorsmod.Wrap(ErrInvalidTrustingPeriod, "trusting period must be greater than zero")
+	}
+	if maxClockDrift < 0 {
+		return nil, errorsmod.Wrapf(ErrInvalidMaxClockDrift, "max clock drift cannot be negative, got: %s", maxClockDrift)
+	}
+	if maxClockDrift == 0 {
+		maxClockDrift = DefaultMaxClockDrift
+	}
+
+	clientState := NewClientState(chainID, uint64(trusting), uint64(unbonding), uint64(maxClockDrift), latestHeight)
+	if err := clientState.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	return clientState, nil
+}
+
+// CreateClientFromCheckpoint creates and validates a new ClientState trusting the given consensus state at the
+// checkpoint height. The checkpoint is rejected if its consensus state is already past the trusting period at now as
+// the client would be expired from creation. On-chain callers must pass the block time to remain deterministic.
+func CreateClientFromCheckpoint(
+	chainID string,
+	trustedHeight clienttypes.Height,
+	trustedConsState *ConsensusState,
+	unbonding, trusting time.Duration,
+	now time.Time,
+) (*ClientState, error) {
+	if trustedConsState == nil {
+		return nil, errorsmod.Wrap(clienttypes.ErrInvalidConsensus, "checkpoint consensus state cannot be nil")
+	}
+	if err := trustedConsState.ValidateBasic(); err != nil {
+		return nil, errorsmod.Wrap(err, "invalid checkpoint consensus state")
+	}
+	if unbonding <= 0 {
+		return nil, errorsmod.Wrap(ErrInvalidUnbondingPeriod, "unbonding period must be greater than zero")
+	}
+	if trusting <= 0 {
+		return nil, errorsmod.Wrap(ErrInvalidTrustingPeriod, "trusting period must be greater than zero")
+	}
+
+	clientState := NewClientState(chainID, uint64(trusting), uint64(unbonding), uint64(DefaultMaxClockDrift), trustedHeight)
+	if err := clientState.Validate(); err != nil {
+		return nil, err
+	}
+
+	if clientState.IsExpired(trustedConsState.GetTime(), now) {
+		return nil, errorsmod.Wrapf(ErrTrustingPeriodExpired, "checkpoint timestamp %s is past the trusting period %s at %s",
+			trustedConsState.GetTime(), trusting, now.UTC())
+	}
+
+	return clientState, nil
+}
+
 // GetChainID returns the chain-id
 func (cs ClientState) GetChainID() string {
 	return cs.ChainId
 }
 
+// Equal returns true if both client states are nil or if all of their fields are equal.
+func (cs *ClientState) Equal(other *ClientState) bool {
+	if cs == nil || other == nil {
+		return cs == other
+	}
+
+	return cs.ChainId == other.ChainId &&
+		cs.TrustingPeriod == other.TrustingPeriod &&
+		cs.UnbondingPeriod == other.UnbondingPeriod &&
+		cs.MaxClockDrift == other.MaxClockDrift &&
+		cs.FrozenHeight.EQ(other.FrozenHeight) &&
+		cs.GetLatestHeight().EQ(other.GetLatestHeight())
+}
+
+// Copy returns a deep copy of the client state, which can be mutated without affecting the original.
+// A nil client state is copied as nil.
+func (cs *ClientState) Copy() *ClientState {
+	if cs == nil {
+		return nil
+	}
+
+	// all fields are currently value types, slice or pointer fields must be explicitly copied here
+	clientState := *cs
+	return &clientState
+}
+
+// Summary returns a human readable rendering of the client state, e.g. for debugging and CLI output.
+// The generated String method renders the protobuf text format instead.
+func (cs ClientState) Summary() string {
+	return fmt.Sprintf(
+		"ClientState{chain_id: %s, latest_height: %s, frozen_height: %s, trusting_period: %s, unbonding_period: %s, max_clock_drift: %s}",
+		cs.ChainId, cs.LatestHeight, cs.FrozenHeight, cs.GetTrustingDuration(), cs.GetUnbondingDuration(), cs.GetMaxClockDrift(),
+	)
+}
+
+// GetTrustingDuration returns the trusting period, persisted in nanoseconds, as a duration.
+func (cs ClientState) GetTrustingDuration() time.Duration {
+	return time.Duration(cs.TrustingPeriod)
+}
+
+// GetUnbondingDuration returns the unbonding period, persisted in nanoseconds, as a duration.
+func (cs ClientState) GetUnbondingDuration() time.Duration {
+	return time.Duration(cs.UnbondingPeriod)
+}
+
+// GetMaxClockDrift returns how much a header time can drift into the future relative to the block time.
+func (cs ClientState) GetMaxClockDrift() time.Duration {
+	return time.Duration(cs.MaxClockDrift)
+}
+
 // ClientType is tendermint.
 func (ClientState) ClientType() string {
 	return ClientType
@@ -52,6 +175,13 @@ func (cs ClientState) GetLatestHeight() exported.Height {
 	return cs.LatestHeight
 }
 
+// NextExpectedHeight returns the height following the client latest height in the same revision, i.e. the lowest
+// height a header must have to update the client. Headers of a new counterparty revision can only be submitted
+// once the client has been upgraded to it.
+func (cs ClientState) NextExpectedHeight() clienttypes.Height {
+	return cs.LatestHeight.Increment().(clienttypes.Height)
+}
+
 // GetTimestampAtHeight returns the timestamp in nanoseconds of the consensus state at the given height.
 func (ClientState) GetTimestampAtHeight(
 	ctx sdk.Context,
@@ -59,10 +189,12 @@ func (ClientState) GetTimestampAtHeight(
 	cdc codec.BinaryCodec,
 	height exported.Height,
 ) (uint64, error) {
+	clientStore = NewReadOnlyStore(clientStore)
+
 	// get consensus state at height from clientStore to check for expiry
 	consState, found := GetConsensusState(clientStore, cdc, height)
 	if !found {
-		return 0, errorsmod.Wrapf(clienttypes.ErrConsensusStateNotFound, "height (%s)", height)
+		return 0, errorsmod.Wrapf(ErrConsensusStateNotFound, "height (%s)", height)
 	}
 	return consState.GetTimestamp(), nil
 }
@@ -80,6 +212,8 @@ func (cs ClientState) Status(
 	clientStore storetypes.KVStore,
 	cdc codec.BinaryCodec,
 ) exported.Status {
+	clientStore = NewReadOnlyStore(clientStore)
+
 	if !cs.FrozenHeight.IsZero() {
 		return exported.Frozen
 	}
@@ -92,21 +226,80 @@ func (cs ClientState) Status(
 		return exported.Expired
 	}
 
-	if cs.IsExpired(consState.Timestamp, uint64(ctx.BlockTime().UnixNano())) {
+	if err := cs.CheckExpiry(ctx, consState, nil); err != nil {
 		return exported.Expired
 	}
 
 	return exported.Active
 }
 
+// GetProofSpecs returns the ICS 23 proof specs of the iavl and tendermint stores the counterparty
+// chain commits its state into.
+func (ClientState) GetProofSpecs() []*ics23.ProofSpec {
+	return commitmenttypes.GetSDKSpecs()
+}
+
+// validateProofSpecs returns an ErrInvalidProofSpecs if the proof specs are empty or contain a nil spec,
+// which would make every membership verification fail.
+func validateProofSpecs(proofSpecs []*ics23.ProofSpec) error {
+	if len(proofSpecs) == 0 {
+		return errorsmod.Wrap(ErrInvalidProofSpecs, "proof specs cannot be empty")
+	}
+	for i, spec := range proofSpecs {
+		if spec == nil {
+			return errorsmod.Wrapf(ErrInvalidProofSpecs, "proof spec cannot be nil at index: %d", i)
+		}
+	}
+	return nil
+}
+
 // IsExpired returns whether or not the client has passed the trusting period since the last
 // update (in which case no headers are considered valid).
-func (cs ClientState) IsExpired(latestTimestamp, now uint64) bool {
-	return latestTimestamp+cs.TrustingPeriod <= now
+func (cs ClientState) IsExpired(latestTimestamp, now time.Time) bool {
+	expirationTime := latestTimestamp.Add(cs.GetTrustingDuration())
+	return !expirationTime.After(now)
 }
 
-// Validate performs a basic validation of the client state fields.
-func (cs ClientState) Validate() error {
+// TimeFn returns the current time the client expiry and the header clock drift are checked against.
+type TimeFn func() time.Time
+
+// currentTime returns the time returned by now, defaulting to the block time when now is nil.
+func currentTime(ctx sdk.Context, now TimeFn) time.Time {
+	if now == nil {
+		return ctx.BlockTime()
+	}
+	return now()
+}
+
+// CheckExpiry returns an ErrTrustingPeriodExpired if the consensus state has passed the trusting period at the time
+// returned by now, or at the block time if now is nil.
+func (cs ClientState) CheckExpiry(ctx sdk.Context, consState *ConsensusState, now TimeFn) error {
+	current := currentTime(ctx, now)
+	if cs.IsExpired(consState.GetTime(), current) {
+		return errorsmod.Wrapf(
+			ErrTrustingPeriodExpired,
+			"consensus state expired (%s + %s <= %s)", consState.GetTime(), cs.GetTrustingDuration(), current,
+		)
+	}
+	return nil
+}
+
+// CheckClockDrift returns an ErrInvalidHeaderTimestamp if the header time is past the max clock drift in relation to
+// the time returned by now, or to the block time if now is nil.
+func (cs ClientState) CheckClockDrift(ctx sdk.Context, headerTime time.Time, now TimeFn) error {
+	current := currentTime(ctx, now)
+	if !headerTime.Before(current.Add(cs.GetMaxClockDrift())) {
+		return errorsmod.Wrapf(
+			ErrInvalidHeaderTimestamp,
+			"header time >= max drift (%s >= %s + %s)", headerTime, current, cs.GetMaxClockDrift(),
+		)
+	}
+	return nil
+}
+
+// ValidateBasic performs the validation of the client state invariants which do not depend on the chain the
+// client is hosted on, so that client states can be validated offline, e.g. when linting relayer configurations.
+func (cs ClientState) ValidateBasic() error {
 	if strings.TrimSpace(cs.ChainId) == "" {
 		return errorsmod.Wrap(ErrInvalidChainID, "chain id cannot be empty string")
 	}
@@ -126,30 +319,51 @@ func (cs ClientState) Validate() error {
 	if cs.UnbondingPeriod <= 0 {
 		return errorsmod.Wrap(ErrInvalidUnbondingPeriod, "unbonding period must be greater than zero")
 	}
+	if cs.TrustingPeriod >= cs.UnbondingPeriod {
+		return errorsmod.Wrapf(
+			ErrInvalidTrustingPeriod,
+			"trusting period (%d) should be < unbonding period (%d)", cs.TrustingPeriod, cs.UnbondingPeriod,
+		)
+	}
+	if cs.LatestHeight.RevisionHeight == 0 {
+		return errorsmod.Wrapf(ErrInvalidHeaderHeight, "tendermint client's latest height revision height cannot be zero")
+	}
+	if cs.FrozenHeight.GT(cs.LatestHeight) {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "frozen height %s cannot be greater than the latest height %s", cs.FrozenHeight, cs.LatestHeight)
+	}
+
+	return nil
+}
+
+// Validate performs a basic validation of the client state fields.
+func (cs ClientState) Validate() error {
+	if err := cs.ValidateBasic(); err != nil {
+		return err
+	}
+
 	if cs.MaxClockDrift <= 0 {
 		return errorsmod.Wrap(ErrInvalidMaxClockDrift, "max clock drift must be greater than zero")
 	}
+	if cs.GetMaxClockDrift() < 0 {
+		return errorsmod.Wrapf(ErrInvalidMaxClockDrift, "max clock drift overflows a duration: %d", cs.MaxClockDrift)
+	}
 
 	// the latest height revision number must match the chain id revision number
-	if cs.LatestHeight.RevisionNumber != clienttypes.ParseChainID(cs.ChainId) {
-		return errorsmod.Wrapf(ErrInvalidHeaderHeight,
-			"latest height revision number must match chain id revision number (%d != %d)", cs.LatestHeight.RevisionNumber, clienttypes.ParseChainID(cs.ChainId))
-	}
-	if cs.LatestHeight.RevisionHeight == 0 {
-		return errorsmod.Wrapf(ErrInvalidHeaderHeight, "tendermint client's latest height revision height cannot be zero")
+	revision, err := parseChainIDRevision(cs.ChainId)
+	if err != nil {
+		return err
 	}
-	if cs.TrustingPeriod >= cs.UnbondingPeriod {
-		return errorsmod.Wrapf(
-			ErrInvalidTrustingPeriod,
-			"trusting period (%d) should be < unbonding period (%d)", cs.TrustingPeriod, cs.UnbondingPeriod,
-		)
+	if cs.LatestHeight.RevisionNumber != revision {
+		return errorsmod.Wrapf(ErrInvalidHeaderHeight,
+			"latest height revision number must match chain id revision number (%d != %d)", cs.LatestHeight.RevisionNumber, revision)
 	}
 
 	return nil
 }
 
 // ZeroCustomFields returns a ClientState that is a copy of the current ClientState
-// with all client customizable fields zeroed out
+// with all client customizable fields zeroed out. Only the chain specified chain-id, unbonding period and
+// latest height are kept, the upgraded client committed by the counterparty chain is compared against it.
 func (cs ClientState) ZeroCustomFields() exported.ClientState {
 	// copy over all chain-specified fields
 	// and leave custom fields empty
@@ -162,6 +376,7 @@ func (cs ClientState) ZeroCustomFields() exported.ClientState {
 
 // Initialize checks that the initial consensus state is an 11-cometbls consensus state and
 // sets the client state, consensus state and associated metadata in the provided client store.
+// The client is tied to the commitment prefix attached to the context, see WithMerklePrefix, which must not be empty.
 func (cs ClientState) Initialize(ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore, consState exported.ConsensusState) error {
 	consensusState, ok := consState.(*ConsensusState)
 	if !ok {
@@ -169,6 +384,10 @@ func (cs ClientState) Initialize(ctx sdk.Context, cdc codec.BinaryCodec, clientS
 			&ConsensusState{}, consState)
 	}
 
+	if err := setMerklePrefix(clientStore, getContextMerklePrefix(ctx)); err != nil {
+		return err
+	}
+
 	setClientState(clientStore, cdc, &cs)
 	setConsensusState(clientStore, cdc, consensusState, cs.GetLatestHeight())
 	setConsensusMetadata(ctx, clientStore, cs.GetLatestHeight())
@@ -190,6 +409,12 @@ func (cs ClientState) VerifyMembership(
 	path exported.Path,
 	value []byte,
 ) error {
+	clientStore = NewReadOnlyStore(clientStore)
+
+	if !cs.FrozenHeight.IsZero() {
+		return clienttypes.ErrClientFrozen
+	}
+
 	if cs.GetLatestHeight().LT(height) {
 		return errorsmod.Wrapf(
 			ibcerrors.ErrInvalidHeight,
@@ -197,7 +422,7 @@ func (cs ClientState) VerifyMembership(
 		)
 	}
 
-	if err := verifyDelayPeriodPassed(ctx, clientStore, height, delayTimePeriod, delayBlockPeriod); err != nil {
+	if err := VerifyDelayPeriodPassed(ctx, clientStore, height, delayTimePeriod, delayBlockPeriod); err != nil {
 		return err
 	}
 
@@ -213,10 +438,146 @@ func (cs ClientState) VerifyMembership(
 
 	consensusState, found := GetConsensusState(clientStore, cdc, height)
 	if !found {
-		return errorsmod.Wrap(clienttypes.ErrConsensusStateNotFound, "please ensure the proof was constructed against a height that exists on the client")
+		return errorsmod.Wrap(ErrConsensusStateNotFound, "please ensure the proof was constructed against a height that exists on the client")
+	}
+
+	return merkleProof.VerifyMembership(cs.GetProofSpecs(), consensusState.GetRoot(), merklePath, value)
+}
+
+// VerifyMembershipAtRoot verifies a proof of the existence of a value at a given CommitmentPath against the provided
+// commitment root. Unlike VerifyMembership, it does not require access to the client store and can be used by off-chain
+// tooling that already holds a trusted consensus state root.
+// An ErrMalformedProof is returned if the proof cannot be decoded and an ErrProofValueMismatch if it does not commit to the value.
+func (cs ClientState) VerifyMembershipAtRoot(
+	root commitmenttypes.MerkleRoot,
+	path exported.Path,
+	proof []byte,
+	value []byte,
+) error {
+	if !cs.FrozenHeight.IsZero() {
+		return clienttypes.ErrClientFrozen
+	}
+
+	return verifyMembership(cs.GetProofSpecs(), root, path, proof, value)
+}
+
+// VerifyPrefixedMembershipAtRoot applies the commitment prefix of the client to the path and verifies the proof with
+// VerifyMembershipAtRoot. Counterparty chains may commit their IBC state under a prefix other than DefaultMerklePrefix,
+// see WithMerklePrefix. The paths given to VerifyMembership are already prefixed by core IBC.
+func (cs ClientState) VerifyPrefixedMembershipAtRoot(
+	clientStore storetypes.KVStore,
+	root commitmenttypes.MerkleRoot,
+	path commitmenttypes.MerklePath,
+	proof []byte,
+	value []byte,
+) error {
+	prefixedPath, err := commitmenttypes.ApplyPrefix(GetMerklePrefix(clientStore), path)
+	if err != nil {
+		return err
 	}
 
-	return merkleProof.VerifyMembership([]*ics23.ProofSpec{ics23.IavlSpec, ics23.TendermintSpec}, consensusState.GetRoot(), merklePath, value)
+	return cs.VerifyMembershipAtRoot(root, prefixedPath, proof, value)
+}
+
+// PacketCommitmentLen is the length of ICS 4 packet commitments, the sha256 hash of the packet timeout and data hash.
+const PacketCommitmentLen = sha256.Size
+
+// VerifyPacketCommitment verifies the proof of a packet commitment with VerifyMembershipAtRoot, after checking that
+// the commitment is PacketCommitmentLen bytes long.
+// An ErrInvalidPacketCommitment is returned if the commitment is malformed.
+func (cs ClientState) VerifyPacketCommitment(
+	root commitmenttypes.MerkleRoot,
+	proof []byte,
+	path exported.Path,
+	commitment []byte,
+) error {
+	if len(commitment) != PacketCommitmentLen {
+		return errorsmod.Wrapf(ErrInvalidPacketCommitment, "packet commitment must be %d bytes, got: %d", PacketCommitmentLen, len(commitment))
+	}
+
+	return cs.VerifyMembershipAtRoot(root, path, proof, commitment)
+}
+
+// MembershipItem is a proof of the existence of a value at a path, verified by VerifyMembershipBatch.
+type MembershipItem struct {
+	Path  exported.Path
+	Proof []byte
+	Value []byte
+}
+
+// VerifyMembershipBatch verifies the proofs of every item against the commitment root, as VerifyMembershipAtRoot
+// does. The returned errors correspond to the items by position, with a nil error for each valid proof.
+func (cs ClientState) VerifyMembershipBatch(root commitmenttypes.MerkleRoot, items []MembershipItem) []error {
+	errs := make([]error, len(items))
+	if !cs.FrozenHeight.IsZero() {
+		for i := range errs {
+			errs[i] = clienttypes.ErrClientFrozen
+		}
+		return errs
+	}
+
+	for i, item := range items {
+		errs[i] = verifyMembership(cs.GetProofSpecs(), root, item.Path, item.Proof, item.Value)
+	}
+	return errs
+}
+
+// GetConsensusState returns the consensus state stored at the given height in the client store. Unlike the package
+// level GetConsensusState, it does not require a codec: the consensus state is decoded from its protobuf Any encoding.
+// An ErrConsensusStateNotFound is returned if there is no consensus state at the height and an ErrInvalidConsensus
+// if the stored bytes are not a cometbls consensus state.
+func (ClientState) GetConsensusState(clientStore storetypes.KVStore, height exported.Height) (*ConsensusState, error) {
+	bz := clientStore.Get(host.ConsensusStateKey(height))
+	if len(bz) == 0 {
+		return nil, errorsmod.Wrapf(ErrConsensusStateNotFound, "no consensus state at height %s", height)
+	}
+
+	var consensusStateAny codectypes.Any
+	if err := consensusStateAny.Unmarshal(bz); err != nil {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "failed to unmarshal consensus state at height %s: %s", height, err)
+	}
+	if typeURL := sdk.MsgTypeURL(&ConsensusState{}); consensusStateAny.TypeUrl != typeURL {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "consensus state at height %s must be a %s, got: %s",
+			height, typeURL, consensusStateAny.TypeUrl)
+	}
+
+	var consensusState ConsensusState
+	if err := consensusState.Unmarshal(consensusStateAny.Value); err != nil {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "failed to unmarshal consensus state at height %s: %s", height, err)
+	}
+
+	return &consensusState, nil
+}
+
+// VerifyMembershipAtHeight verifies the proof with VerifyMembershipAtRoot against the root of the consensus state
+// stored at the given height. Unlike VerifyMembership, no delay period is enforced.
+// Consensus states are looked up by both revision number and height, a proof height of a revision prior to the
+// client latest height, e.g. after an upgrade, is verified against the root of that earlier revision.
+// An ErrInvalidHeight is returned if the height is above the client latest height and an ErrConsensusStateNotFound
+// if the client has no consensus state at the height.
+func (cs ClientState) VerifyMembershipAtHeight(
+	clientStore storetypes.KVStore,
+	cdc codec.BinaryCodec,
+	height exported.Height,
+	path exported.Path,
+	proof []byte,
+	value []byte,
+) error {
+	clientStore = NewReadOnlyStore(clientStore)
+
+	if cs.GetLatestHeight().LT(height) {
+		return errorsmod.Wrapf(
+			ibcerrors.ErrInvalidHeight,
+			"client state height < proof height (%d < %d), please ensure the client has been updated", cs.GetLatestHeight(), height,
+		)
+	}
+
+	consensusState, found := GetConsensusState(clientStore, cdc, height)
+	if !found {
+		return errorsmod.Wrapf(ErrConsensusStateNotFound, "no consensus state at height %s", height)
+	}
+
+	return cs.VerifyMembershipAtRoot(consensusState.Root, path, proof, value)
 }
 
 // VerifyNonMembership is a generic proof verification method which verifies the absence of a given CommitmentPath at a specified height.
@@ -232,6 +593,12 @@ func (cs ClientState) VerifyNonMembership(
 	proof []byte,
 	path exported.Path,
 ) error {
+	clientStore = NewReadOnlyStore(clientStore)
+
+	if !cs.FrozenHeight.IsZero() {
+		return clienttypes.ErrClientFrozen
+	}
+
 	if cs.GetLatestHeight().LT(height) {
 		return errorsmod.Wrapf(
 			ibcerrors.ErrInvalidHeight,
@@ -239,7 +606,7 @@ func (cs ClientState) VerifyNonMembership(
 		)
 	}
 
-	if err := verifyDelayPeriodPassed(ctx, clientStore, height, delayTimePeriod, delayBlockPeriod); err != nil {
+	if err := VerifyDelayPeriodPassed(ctx, clientStore, height, delayTimePeriod, delayBlockPeriod); err != nil {
 		return err
 	}
 
@@ -255,15 +622,57 @@ func (cs ClientState) VerifyNonMembership(
 
 	consensusState, found := GetConsensusState(clientStore, cdc, height)
 	if !found {
-		return errorsmod.Wrap(clienttypes.ErrConsensusStateNotFound, "please ensure the proof was constructed against a height that exists on the client")
+		return errorsmod.Wrap(ErrConsensusStateNotFound, "please ensure the proof was constructed against a height that exists on the client")
+	}
+
+	return merkleProof.VerifyNonMembership(cs.GetProofSpecs(), consensusState.GetRoot(), merklePath)
+}
+
+// VerifyNonMembershipAtRoot verifies a proof of the absence of a given CommitmentPath against the provided commitment root.
+// Unlike VerifyNonMembership, it does not require access to the client store.
+// An ErrMalformedProof is returned if the proof cannot be decoded and an ErrProofKeyPresent if it does not prove the absence of the key.
+func (cs ClientState) VerifyNonMembershipAtRoot(
+	root commitmenttypes.MerkleRoot,
+	path exported.Path,
+	proof []byte,
+) error {
+	if !cs.FrozenHeight.IsZero() {
+		return clienttypes.ErrClientFrozen
+	}
+
+	merkleProof, merklePath, err := decodeMerkleProof(path, proof)
+	if err != nil {
+		return err
+	}
+
+	if err := merkleProof.VerifyNonMembership(cs.GetProofSpecs(), root, merklePath); err != nil {
+		return errorsmod.Wrap(ErrProofKeyPresent, err.Error())
+	}
+
+	return nil
+}
+
+// VerifyPrefixedNonMembershipAtRoot applies the commitment prefix of the client to the path and verifies the proof with
+// VerifyNonMembershipAtRoot.
+func (cs ClientState) VerifyPrefixedNonMembershipAtRoot(
+	clientStore storetypes.KVStore,
+	root commitmenttypes.MerkleRoot,
+	path commitmenttypes.MerklePath,
+	proof []byte,
+) error {
+	prefixedPath, err := commitmenttypes.ApplyPrefix(GetMerklePrefix(clientStore), path)
+	if err != nil {
+		return err
 	}
 
-	return merkleProof.VerifyNonMembership([]*ics23.ProofSpec{ics23.IavlSpec, ics23.TendermintSpec}, consensusState.GetRoot(), merklePath)
+	return cs.VerifyNonMembershipAtRoot(root, prefixedPath, proof)
 }
 
-// verifyDelayPeriodPassed will ensure that at least delayTimePeriod amount of time and delayBlockPeriod number of blocks have passed
+// VerifyDelayPeriodPassed will ensure that at least delayTimePeriod amount of time and delayBlockPeriod number of blocks have passed
 // since consensus state was submitted before allowing verification to continue.
-func verifyDelayPeriodPassed(ctx sdk.Context, store storetypes.KVStore, proofHeight exported.Height, delayTimePeriod, delayBlockPeriod uint64) error {
+// An ErrDelayPeriodNotPassed is returned otherwise, and an ErrProcessedTimeNotFound or ErrProcessedHeightNotFound if
+// the metadata of the consensus state at the proof height is missing.
+func VerifyDelayPeriodPassed(ctx sdk.Context, store storetypes.KVStore, proofHeight exported.Height, delayTimePeriod, delayBlockPeriod uint64) error {
 	if delayTimePeriod != 0 {
 		// check that executing chain's timestamp has passed consensusState's processed time + delay time period
 		processedTime, ok := GetProcessedTime(store, proofHeight)
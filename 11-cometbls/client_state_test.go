@@ -0,0 +1,938 @@
+package cometbls
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/store/metrics"
+	"cosmossdk.io/store/rootmulti"
+	storetypes "cosmossdk.io/store/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	channeltypes "github.com/cosmos/ibc-go/v8/modules/core/04-channel/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	host "github.com/cosmos/ibc-go/v8/modules/core/24-host"
+	ibcerrors "github.com/cosmos/ibc-go/v8/modules/core/errors"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+	ics23 "github.com/cosmos/ics23/go"
+	"github.com/stretchr/testify/require"
+)
+
+const testStoreKey = "ibc"
+
+// newTestProof commits the provided key/value pair in an IAVL store mounted on a multistore and returns the
+// resulting commitment root along with an ICS 23 proof of the queried key.
+func newTestProof(t *testing.T, key, value, queriedKey []byte) (commitmenttypes.MerkleRoot, commitmenttypes.MerklePath, []byte) {
+	t.Helper()
+
+	return newTestProofWithStoreKey(t, testStoreKey, key, value, queriedKey)
+}
+
+// newTestProofWithStoreKey is like newTestProof, committing the key/value pair in the store mounted under the given key.
+func newTestProofWithStoreKey(t *testing.T, storeKeyName string, key, value, queriedKey []byte) (commitmenttypes.MerkleRoot, commitmenttypes.MerklePath, []byte) {
+	t.Helper()
+
+	store := rootmulti.NewStore(dbm.NewMemDB(), log.NewNopLogger(), metrics.NewNoOpMetrics())
+	storeKey := storetypes.NewKVStoreKey(storeKeyName)
+	store.MountStoreWithDB(storeKey, storetypes.StoreTypeIAVL, nil)
+	require.NoError(t, store.LoadVersion(0))
+
+	store.GetCommitKVStore(storeKey).Set(key, value)
+	cid := store.Commit()
+
+	res, err := store.Query(&storetypes.RequestQuery{
+		Path:  fmt.Sprintf("/%s/key", storeKeyName),
+		Data:  queriedKey,
+		Prove: true,
+	})
+	require.NoError(t, err)
+
+	merkleProof, err := commitmenttypes.ConvertProofs(res.ProofOps)
+	require.NoError(t, err)
+
+	proof, err := merkleProof.Marshal()
+	require.NoError(t, err)
+
+	return commitmenttypes.NewMerkleRoot(cid.Hash), commitmenttypes.NewMerklePath(storeKeyName, string(queriedKey)), proof
+}
+
+func newTestClientState() *ClientState {
+	return NewClientState(testChainID, 100, 200, 10, clienttypes.NewHeight(1, 5))
+}
+
+func TestVerifyMembershipAtRoot(t *testing.T) {
+	key, value := []byte("clients/07-tendermint-0/clientState"), []byte("value")
+	root, path, proof := newTestProof(t, key, value, key)
+
+	testCases := []struct {
+		name   string
+		proof  []byte
+		value  []byte
+		expErr error
+	}{
+		{"valid proof", proof, value, nil},
+		{"tampered value", proof, []byte("tampered"), ErrProofValueMismatch},
+		{"malformed proof", []byte("malformed"), value, ErrMalformedProof},
+		{"empty proof", nil, value, ErrMalformedProof},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newTestClientState().VerifyMembershipAtRoot(root, path, tc.proof, tc.value)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyNonMembershipAtRoot(t *testing.T) {
+	key, value := []byte("clients/07-tendermint-0/clientState"), []byte("value")
+	absentKey := []byte("clients/07-tendermint-1/clientState")
+	root, path, absenceProof := newTestProof(t, key, value, absentKey)
+	_, presentPath, existenceProof := newTestProof(t, key, value, key)
+
+	testCases := []struct {
+		name   string
+		path   commitmenttypes.MerklePath
+		proof  []byte
+		expErr error
+	}{
+		{"valid absence proof", path, absenceProof, nil},
+		{"key is present", presentPath, existenceProof, ErrProofKeyPresent},
+		{"absence proof of another key", presentPath, absenceProof, ErrProofKeyPresent},
+		{"malformed proof", path, []byte("malformed"), ErrMalformedProof},
+		{"empty proof", path, nil, ErrMalformedProof},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newTestClientState().VerifyNonMembershipAtRoot(root, tc.path, tc.proof)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyPrefixedMembershipAtRoot(t *testing.T) {
+	key, value := []byte("clients/07-tendermint-0/clientState"), []byte("value")
+	absentKey := []byte("clients/07-tendermint-1/clientState")
+	customPrefix := commitmenttypes.NewMerklePrefix([]byte("custom"))
+	root, _, proof := newTestProofWithStoreKey(t, "custom", key, value, key)
+	_, _, absenceProof := newTestProofWithStoreKey(t, "custom", key, value, absentKey)
+
+	testCases := []struct {
+		name                string
+		malleate            func(ctx sdk.Context) sdk.Context
+		expErr              error
+		expNonMembershipErr error
+	}{
+		{"custom prefix", func(ctx sdk.Context) sdk.Context { return WithMerklePrefix(ctx, customPrefix) }, nil, nil},
+		{"default prefix", func(ctx sdk.Context) sdk.Context { return ctx }, ErrProofValueMismatch, ErrProofKeyPresent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := tc.malleate(newTestContext(testChainID, 10))
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			consensusState, err := NewConsensusState(uint64(ctx.BlockTime().UnixNano()), root, testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+			err = clientState.VerifyPrefixedMembershipAtRoot(clientStore, root, commitmenttypes.NewMerklePath(string(key)), proof, value)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+
+			err = clientState.VerifyPrefixedNonMembershipAtRoot(clientStore, root, commitmenttypes.NewMerklePath(string(absentKey)), absenceProof)
+			if tc.expNonMembershipErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expNonMembershipErr)
+			}
+		})
+	}
+}
+
+func TestInitializeMerklePrefix(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	consensusState, err := NewConsensusState(uint64(ctx.BlockTime().UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+
+	// clients are created under the default prefix unless another one is attached to the context
+	clientStore := newTestClientStore()
+	require.Equal(t, DefaultMerklePrefix, GetMerklePrefix(clientStore))
+	require.NoError(t, newTestClientState().Initialize(ctx, cdc, clientStore, consensusState))
+	require.Equal(t, DefaultMerklePrefix, GetMerklePrefix(clientStore))
+
+	customPrefix := commitmenttypes.NewMerklePrefix([]byte("custom"))
+	clientStore = newTestClientStore()
+	require.NoError(t, newTestClientState().Initialize(WithMerklePrefix(ctx, customPrefix), cdc, clientStore, consensusState))
+	require.Equal(t, customPrefix, GetMerklePrefix(clientStore))
+
+	// a client cannot be created with an empty prefix
+	clientStore = newTestClientStore()
+	err = newTestClientState().Initialize(WithMerklePrefix(ctx, commitmenttypes.NewMerklePrefix(nil)), cdc, clientStore, consensusState)
+	require.ErrorIs(t, err, commitmenttypes.ErrInvalidPrefix)
+	_, found := getClientState(clientStore, cdc)
+	require.False(t, found)
+}
+
+func TestVerifyPacketCommitment(t *testing.T) {
+	packet := channeltypes.NewPacket([]byte("data"), 1, "transfer", "channel-0", "transfer", "channel-1", clienttypes.NewHeight(1, 100), 0)
+	commitment := channeltypes.CommitPacket(newTestCodec(), packet)
+	key := host.PacketCommitmentKey(packet.SourcePort, packet.SourceChannel, packet.Sequence)
+	root, path, proof := newTestProof(t, key, commitment, key)
+
+	testCases := []struct {
+		name       string
+		commitment []byte
+		expErr     error
+	}{
+		{"valid packet commitment", commitment, nil},
+		{"mismatched packet commitment", make([]byte, PacketCommitmentLen), ErrProofValueMismatch},
+		{"truncated packet commitment", commitment[:PacketCommitmentLen-1], ErrInvalidPacketCommitment},
+		{"extended packet commitment", append(append([]byte{}, commitment...), 0), ErrInvalidPacketCommitment},
+		{"raw packet data", packet.Data, ErrInvalidPacketCommitment},
+		{"empty packet commitment", nil, ErrInvalidPacketCommitment},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newTestClientState().VerifyPacketCommitment(root, proof, path, tc.commitment)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyMembershipBatch(t *testing.T) {
+	key, value := []byte("clients/07-tendermint-0/clientState"), []byte("value")
+	root, path, proof := newTestProof(t, key, value, key)
+	_, otherPath, otherProof := newTestProof(t, key, value, []byte("clients/07-tendermint-1/clientState"))
+
+	items := []MembershipItem{
+		{Path: path, Proof: proof, Value: value},
+		{Path: path, Proof: proof, Value: []byte("tampered")},
+		{Path: path, Proof: proof, Value: value},
+		{Path: path, Proof: []byte("malformed"), Value: value},
+		{Path: otherPath, Proof: otherProof, Value: value},
+	}
+	expErrs := []error{nil, ErrProofValueMismatch, nil, ErrMalformedProof, ErrProofValueMismatch}
+
+	clientState := newTestClientState()
+	errs := clientState.VerifyMembershipBatch(root, items)
+	require.Len(t, errs, len(items))
+	for i, expErr := range expErrs {
+		if expErr == nil {
+			require.NoError(t, errs[i], "item %d", i)
+		} else {
+			require.ErrorIs(t, errs[i], expErr, "item %d", i)
+		}
+	}
+
+	require.Empty(t, clientState.VerifyMembershipBatch(root, nil))
+
+	clientState.FrozenHeight = FrozenHeight
+	for _, err := range clientState.VerifyMembershipBatch(root, items) {
+		require.ErrorIs(t, err, clienttypes.ErrClientFrozen)
+	}
+}
+
+func TestClientStateGetConsensusState(t *testing.T) {
+	cdc := newTestCodec()
+	height := clienttypes.NewHeight(1, 5)
+	consensusState, err := NewConsensusState(5, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		malleate func(clientStore storetypes.KVStore)
+		expErr   error
+	}{
+		{
+			"consensus state present",
+			func(clientStore storetypes.KVStore) {},
+			nil,
+		},
+		{
+			"consensus state absent",
+			func(clientStore storetypes.KVStore) {
+				clientStore.Delete(host.ConsensusStateKey(height))
+			},
+			ErrConsensusStateNotFound,
+		},
+		{
+			"corrupt bytes",
+			func(clientStore storetypes.KVStore) {
+				clientStore.Set(host.ConsensusStateKey(height), []byte("corrupt"))
+			},
+			clienttypes.ErrInvalidConsensus,
+		},
+		{
+			"corrupt consensus state",
+			func(clientStore storetypes.KVStore) {
+				bz, err := (&codectypes.Any{TypeUrl: sdk.MsgTypeURL(&ConsensusState{}), Value: []byte("corrupt")}).Marshal()
+				require.NoError(t, err)
+				clientStore.Set(host.ConsensusStateKey(height), bz)
+			},
+			clienttypes.ErrInvalidConsensus,
+		},
+		{
+			"client state stored instead of a consensus state",
+			func(clientStore storetypes.KVStore) {
+				clientStore.Set(host.ConsensusStateKey(height), clienttypes.MustMarshalClientState(cdc, newTestClientState()))
+			},
+			clienttypes.ErrInvalidConsensus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientStore := newTestClientStore()
+			setConsensusState(clientStore, cdc, consensusState, height)
+			tc.malleate(clientStore)
+
+			found, err := newTestClientState().GetConsensusState(clientStore, height)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.True(t, consensusState.Equal(found))
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, found)
+			}
+		})
+	}
+}
+
+func TestVerifyMembershipAtHeight(t *testing.T) {
+	key, value := []byte("clients/07-tendermint-0/clientState"), []byte("value")
+	root, path, proof := newTestProof(t, key, value, key)
+	height := clienttypes.NewHeight(1, 5)
+
+	testCases := []struct {
+		name   string
+		height exported.Height
+		value  []byte
+		expErr error
+	}{
+		{"consensus state at height", height, value, nil},
+		{"tampered value", height, []byte("tampered"), ErrProofValueMismatch},
+		{"no consensus state at height", clienttypes.NewHeight(1, 4), value, ErrConsensusStateNotFound},
+		{"height above the latest height", clienttypes.NewHeight(1, 6), value, ibcerrors.ErrInvalidHeight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+			setConsensusState(clientStore, cdc, &ConsensusState{Timestamp: 1, Root: root, NextValidatorsHash: testNextValidatorsHash}, height)
+
+			err := newTestClientState().VerifyMembershipAtHeight(clientStore, cdc, tc.height, path, proof, tc.value)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyMembershipAtHeightAcrossRevisions(t *testing.T) {
+	key := []byte("clients/07-tendermint-0/clientState")
+	oldValue, newValue := []byte("old revision value"), []byte("new revision value")
+	oldRoot, path, oldProof := newTestProof(t, key, oldValue, key)
+	newRoot, _, newProof := newTestProof(t, key, newValue, key)
+	require.NotEqual(t, oldRoot, newRoot)
+
+	// the client was upgraded from revision 1 at height 5 to revision 2, in which it was updated at height 5
+	oldHeight, upgradeHeight, newHeight := clienttypes.NewHeight(1, 5), clienttypes.NewHeight(2, 1), clienttypes.NewHeight(2, 5)
+	clientState := newTestClientState()
+	clientState.ChainId = "union-devnet-2"
+	clientState.LatestHeight = newHeight
+
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+	setConsensusState(clientStore, cdc, &ConsensusState{Timestamp: 1, Root: oldRoot, NextValidatorsHash: testNextValidatorsHash}, oldHeight)
+	setConsensusState(clientStore, cdc, &ConsensusState{
+		Timestamp: 2, Root: commitmenttypes.NewMerkleRoot([]byte(SentinelRoot)), NextValidatorsHash: testNextValidatorsHash,
+	}, upgradeHeight)
+	setConsensusState(clientStore, cdc, &ConsensusState{Timestamp: 3, Root: newRoot, NextValidatorsHash: testNextValidatorsHash}, newHeight)
+
+	testCases := []struct {
+		name   string
+		height exported.Height
+		proof  []byte
+		value  []byte
+		expErr error
+	}{
+		{"earlier revision proof against the earlier revision root", oldHeight, oldProof, oldValue, nil},
+		{"latest revision proof against the latest revision root", newHeight, newProof, newValue, nil},
+		{"earlier revision proof against the latest revision root", newHeight, oldProof, oldValue, ErrProofValueMismatch},
+		{"latest revision proof against the earlier revision root", oldHeight, newProof, newValue, ErrProofValueMismatch},
+		{"earlier revision proof against the upgrade sentinel root", upgradeHeight, oldProof, oldValue, ErrProofValueMismatch},
+		{"earlier revision height in the latest revision", clienttypes.NewHeight(2, oldHeight.RevisionHeight-1), oldProof, oldValue, ErrConsensusStateNotFound},
+		{"latest revision height in the earlier revision", clienttypes.NewHeight(1, upgradeHeight.RevisionHeight), oldProof, oldValue, ErrConsensusStateNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := clientState.VerifyMembershipAtHeight(clientStore, cdc, tc.height, path, tc.proof, tc.value)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestPeriodDurations(t *testing.T) {
+	const (
+		ubdPeriod      = 21 * 24 * time.Hour
+		trustingPeriod = 14 * 24 * time.Hour
+	)
+
+	clientState := NewClientState(testChainID, uint64(trustingPeriod), uint64(ubdPeriod), uint64(time.Minute), clienttypes.NewHeight(1, 5))
+	require.Equal(t, trustingPeriod, clientState.GetTrustingDuration())
+	require.Equal(t, ubdPeriod, clientState.GetUnbondingDuration())
+
+	// the self client periods are accepted exactly when the durations match the host periods
+	host := NewConsensusHost(
+		mockStakingKeeper{ubdPeriod: clientState.GetUnbondingDuration()},
+		WithTrustingPeriodFn(func(sdk.Context) (time.Duration, error) { return clientState.GetTrustingDuration(), nil }),
+	)
+	require.NoError(t, host.ValidateSelfClient(newTestContext(testChainID, 10), clientState))
+
+	host = NewConsensusHost(
+		mockStakingKeeper{ubdPeriod: ubdPeriod},
+		WithTrustingPeriodFn(func(sdk.Context) (time.Duration, error) { return trustingPeriod, nil }),
+	)
+	clientState.TrustingPeriod = uint64(trustingPeriod / time.Second)
+	require.Equal(t, trustingPeriod/time.Second, clientState.GetTrustingDuration())
+	require.ErrorIs(t, host.ValidateSelfClient(newTestContext(testChainID, 10), clientState), clienttypes.ErrInvalidClient)
+}
+
+func TestIsExpired(t *testing.T) {
+	clientState := newTestClientState()
+	latestTimestamp := time.Unix(0, 1_000).UTC()
+	expirationTime := latestTimestamp.Add(clientState.GetTrustingDuration())
+
+	testCases := []struct {
+		name       string
+		now        time.Time
+		expExpired bool
+	}{
+		{"just before expiry", expirationTime.Add(-time.Nanosecond), false},
+		{"at expiry", expirationTime, true},
+		{"just after expiry", expirationTime.Add(time.Nanosecond), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expExpired, clientState.IsExpired(latestTimestamp, tc.now))
+		})
+	}
+}
+
+func TestClockFn(t *testing.T) {
+	clientState := newTestClientState()
+	consensusState := &ConsensusState{Timestamp: 1_000, Root: commitmenttypes.NewMerkleRoot(testAppHash), NextValidatorsHash: testNextValidatorsHash}
+	expirationTime := consensusState.GetTime().Add(clientState.GetTrustingDuration())
+	headerTime := consensusState.GetTime().Add(time.Nanosecond)
+
+	testCases := []struct {
+		name        string
+		now         time.Time
+		expExpired  bool
+		expDriftErr bool
+	}{
+		{"before expiry", expirationTime.Add(-time.Nanosecond), false, false},
+		{"at expiry", expirationTime, true, false},
+		{"header past the max clock drift", headerTime.Add(-clientState.GetMaxClockDrift()), false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := func() time.Time { return tc.now }
+
+			// the clock takes precedence over the block time, no context needs to be built
+			err := clientState.CheckExpiry(sdk.Context{}, consensusState, now)
+			if tc.expExpired {
+				require.ErrorIs(t, err, ErrTrustingPeriodExpired)
+			} else {
+				require.NoError(t, err)
+			}
+
+			err = clientState.CheckClockDrift(sdk.Context{}, headerTime, now)
+			if tc.expDriftErr {
+				require.ErrorIs(t, err, ErrInvalidHeaderTimestamp)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// without a clock the block time is used
+	ctx := newTestContext(testChainID, 10).WithBlockTime(expirationTime)
+	require.ErrorIs(t, clientState.CheckExpiry(ctx, consensusState, nil), ErrTrustingPeriodExpired)
+	require.NoError(t, clientState.CheckExpiry(ctx.WithBlockTime(expirationTime.Add(-time.Nanosecond)), consensusState, nil))
+}
+
+func TestStatus(t *testing.T) {
+	testCases := []struct {
+		name      string
+		malleate  func(clientState *ClientState)
+		blockTime uint64
+		expStatus exported.Status
+	}{
+		{
+			"client is active",
+			func(_ *ClientState) {},
+			1 + 99,
+			exported.Active,
+		},
+		{
+			"client is frozen",
+			func(clientState *ClientState) {
+				clientState.FrozenHeight = FrozenHeight
+			},
+			1 + 99,
+			exported.Frozen,
+		},
+		{
+			"client is expired",
+			func(_ *ClientState) {},
+			1 + 100,
+			exported.Expired,
+		},
+		{
+			"latest consensus state not found",
+			func(clientState *ClientState) {
+				clientState.LatestHeight = clientState.LatestHeight.Increment().(clienttypes.Height)
+			},
+			1 + 99,
+			exported.Expired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(testChainID, 10)
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			consensusState, err := NewConsensusState(1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+			tc.malleate(clientState)
+
+			ctx = ctx.WithBlockTime(time.Unix(0, int64(tc.blockTime)))
+			require.Equal(t, tc.expStatus, clientState.Status(ctx, clientStore, cdc))
+		})
+	}
+}
+
+func TestClientStateEqual(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(clientState *ClientState)
+		expEqual bool
+	}{
+		{"equal", func(_ *ClientState) {}, true},
+		{"different chain id", func(cs *ClientState) { cs.ChainId = "union-devnet-2" }, false},
+		{"different trusting period", func(cs *ClientState) { cs.TrustingPeriod++ }, false},
+		{"different unbonding period", func(cs *ClientState) { cs.UnbondingPeriod++ }, false},
+		{"different max clock drift", func(cs *ClientState) { cs.MaxClockDrift++ }, false},
+		{"different frozen height", func(cs *ClientState) { cs.FrozenHeight = FrozenHeight }, false},
+		{"different latest height", func(cs *ClientState) { cs.LatestHeight = clienttypes.NewHeight(1, 6) }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState := newTestClientState()
+			tc.malleate(clientState)
+			require.Equal(t, tc.expEqual, newTestClientState().Equal(clientState))
+			require.Equal(t, tc.expEqual, clientState.Equal(newTestClientState()))
+		})
+	}
+
+	var nilClientState *ClientState
+	require.True(t, nilClientState.Equal(nil))
+	require.False(t, nilClientState.Equal(newTestClientState()))
+	require.False(t, newTestClientState().Equal(nil))
+}
+
+func TestGetTimestampAtHeight(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	timestamp := time.Unix(1710783278, 499600406)
+	clientState := newTestClientState()
+	consensusState, err := NewConsensusState(uint64(timestamp.UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+	require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+	actual, err := clientState.GetTimestampAtHeight(ctx, clientStore, cdc, clientState.LatestHeight)
+	require.NoError(t, err)
+	require.Equal(t, uint64(timestamp.UnixNano()), actual)
+	require.True(t, timestamp.Equal(time.Unix(0, int64(actual))))
+
+	_, err = clientState.GetTimestampAtHeight(ctx, clientStore, cdc, clientState.LatestHeight.Increment())
+	require.ErrorIs(t, err, ErrConsensusStateNotFound)
+}
+
+func TestClientStateValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(clientState *ClientState)
+		expErr   error
+	}{
+		{"valid client state", func(_ *ClientState) {}, nil},
+		{"empty chain id", func(cs *ClientState) { cs.ChainId = "" }, ErrInvalidChainID},
+		{"chain id revision overflowing a uint64", func(cs *ClientState) { cs.ChainId = "chain-18446744073709551616" }, ErrInvalidChainID},
+		{"zero trusting period", func(cs *ClientState) { cs.TrustingPeriod = 0 }, ErrInvalidTrustingPeriod},
+		{"zero unbonding period", func(cs *ClientState) { cs.UnbondingPeriod = 0 }, ErrInvalidUnbondingPeriod},
+		{"zero max clock drift", func(cs *ClientState) { cs.MaxClockDrift = 0 }, ErrInvalidMaxClockDrift},
+		{"max clock drift overflowing a duration", func(cs *ClientState) { cs.MaxClockDrift = math.MaxInt64 + 1 }, ErrInvalidMaxClockDrift},
+		{"zero latest height", func(cs *ClientState) { cs.LatestHeight = clienttypes.NewHeight(1, 0) }, ErrInvalidHeaderHeight},
+		{"frozen at the sentinel height", func(cs *ClientState) { cs.FrozenHeight = FrozenHeight }, nil},
+		{"frozen height above the latest height", func(cs *ClientState) { cs.FrozenHeight = clienttypes.NewHeight(1, 6) }, clienttypes.ErrInvalidHeight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState := newTestClientState()
+			tc.malleate(clientState)
+
+			var err error
+			require.NotPanics(t, func() { err = clientState.Validate() })
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestClientStateValidateBasic(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(clientState *ClientState)
+		expErr   error
+	}{
+		{"valid client state", func(_ *ClientState) {}, nil},
+		{"unbonding period equal to trusting period", func(cs *ClientState) { cs.TrustingPeriod = cs.UnbondingPeriod }, ErrInvalidTrustingPeriod},
+		// context independent invariants only, the chain revision and max clock drift are checked by Validate
+		{"latest height in another revision", func(cs *ClientState) { cs.LatestHeight = clienttypes.NewHeight(2, 5) }, nil},
+		{"zero max clock drift", func(cs *ClientState) { cs.MaxClockDrift = 0 }, nil},
+		{"empty chain id", func(cs *ClientState) { cs.ChainId = "" }, ErrInvalidChainID},
+		{"blank chain id", func(cs *ClientState) { cs.ChainId = "  " }, ErrInvalidChainID},
+		{"chain id too long", func(cs *ClientState) { cs.ChainId = strings.Repeat("a", cmttypes.MaxChainIDLen+1) }, ErrInvalidChainID},
+		{"zero trusting period", func(cs *ClientState) { cs.TrustingPeriod = 0 }, ErrInvalidTrustingPeriod},
+		{"zero unbonding period", func(cs *ClientState) { cs.UnbondingPeriod = 0 }, ErrInvalidUnbondingPeriod},
+		{"unbonding period lower than trusting period", func(cs *ClientState) { cs.TrustingPeriod = cs.UnbondingPeriod + 1 }, ErrInvalidTrustingPeriod},
+		{"zero latest height", func(cs *ClientState) { cs.LatestHeight = clienttypes.NewHeight(1, 0) }, ErrInvalidHeaderHeight},
+		{"frozen at the sentinel height", func(cs *ClientState) { cs.FrozenHeight = FrozenHeight }, nil},
+		{"frozen height above the latest height", func(cs *ClientState) { cs.FrozenHeight = clienttypes.NewHeight(1, 6) }, clienttypes.ErrInvalidHeight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState := newTestClientState()
+			tc.malleate(clientState)
+
+			err := clientState.ValidateBasic()
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestZeroCustomFields(t *testing.T) {
+	clientState := newTestClientState()
+	clientState.FrozenHeight = FrozenHeight
+
+	zeroed, ok := clientState.ZeroCustomFields().(*ClientState)
+	require.True(t, ok)
+	require.Equal(t, &ClientState{
+		ChainId:         clientState.ChainId,
+		UnbondingPeriod: clientState.UnbondingPeriod,
+		LatestHeight:    clientState.LatestHeight,
+	}, zeroed)
+
+	// the original client state is left untouched
+	require.Equal(t, FrozenHeight, clientState.FrozenHeight)
+	require.Equal(t, uint64(100), clientState.TrustingPeriod)
+	require.Equal(t, uint64(10), clientState.MaxClockDrift)
+}
+
+func TestClientStateCopy(t *testing.T) {
+	clientState := newTestClientState()
+
+	copied := clientState.Copy()
+	require.True(t, clientState.Equal(copied))
+
+	copied.ChainId = "union-devnet-2"
+	copied.TrustingPeriod++
+	copied.FrozenHeight = FrozenHeight
+	copied.LatestHeight.RevisionHeight++
+	require.True(t, newTestClientState().Equal(clientState))
+
+	var nilClientState *ClientState
+	require.Nil(t, nilClientState.Copy())
+}
+
+func TestClientStateSummary(t *testing.T) {
+	summary := newTestClientState().Summary()
+	require.Contains(t, summary, "chain_id: "+testChainID)
+	require.Contains(t, summary, "latest_height: 1-5")
+	require.Contains(t, summary, "trusting_period: 100ns")
+}
+
+func TestClientStateJSON(t *testing.T) {
+	cdc := newTestCodec().(codec.Codec)
+
+	clientState := newTestClientState()
+	clientState.FrozenHeight = FrozenHeight
+
+	bz, err := cdc.MarshalJSON(clientState)
+	require.NoError(t, err)
+	require.Contains(t, string(bz), `"chain_id":"`+testChainID+`"`)
+	require.Contains(t, string(bz), `"latest_height":{"revision_number":"1","revision_height":"5"}`)
+
+	var unmarshalled ClientState
+	require.NoError(t, cdc.UnmarshalJSON(bz, &unmarshalled))
+	require.True(t, clientState.Equal(&unmarshalled))
+}
+
+func TestDefaultClientState(t *testing.T) {
+	const (
+		unbonding = 3 * time.Hour
+		trusting  = 2 * time.Hour
+	)
+	latestHeight := clienttypes.NewHeight(1, 5)
+
+	clientState, err := DefaultClientState(testChainID, latestHeight, unbonding, trusting, 0)
+	require.NoError(t, err)
+	require.Equal(t, testChainID, clientState.ChainId)
+	require.Equal(t, latestHeight, clientState.LatestHeight)
+	require.Equal(t, unbonding, clientState.GetUnbondingDuration())
+	require.Equal(t, trusting, clientState.GetTrustingDuration())
+	require.Equal(t, DefaultMaxClockDrift, clientState.GetMaxClockDrift())
+	require.True(t, clientState.FrozenHeight.IsZero())
+	require.Equal(t, commitmenttypes.GetSDKSpecs(), clientState.GetProofSpecs())
+	require.NoError(t, clientState.Validate())
+
+	testCases := []struct {
+		name          string
+		chainID       string
+		latestHeight  clienttypes.Height
+		unbonding     time.Duration
+		trusting      time.Duration
+		maxClockDrift time.Duration
+		expErr        error
+	}{
+		{"explicit parameters", testChainID, latestHeight, unbonding, trusting, time.Minute, nil},
+		{"empty chain-id", "", latestHeight, unbonding, trusting, 0, ErrInvalidChainID},
+		{"zero latest height", testChainID, clienttypes.ZeroHeight(), unbonding, trusting, 0, ErrInvalidHeaderHeight},
+		{"zero unbonding period", testChainID, latestHeight, 0, trusting, 0, ErrInvalidUnbondingPeriod},
+		{"negative trusting period", testChainID, latestHeight, unbonding, -trusting, 0, ErrInvalidTrustingPeriod},
+		{"trusting period above the unbonding period", testChainID, latestHeight, trusting, unbonding, 0, ErrInvalidTrustingPeriod},
+		{"negative max clock drift", testChainID, latestHeight, unbonding, trusting, -time.Minute, ErrInvalidMaxClockDrift},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState, err := DefaultClientState(tc.chainID, tc.latestHeight, tc.unbonding, tc.trusting, tc.maxClockDrift)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.NoError(t, clientState.ValidateBasic())
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, clientState)
+			}
+		})
+	}
+}
+
+func TestCreateClientFromCheckpoint(t *testing.T) {
+	const (
+		unbonding = 3 * time.Hour
+		trusting  = 2 * time.Hour
+	)
+	now := time.Unix(1_700_000_000, 0)
+
+	testCases := []struct {
+		name           string
+		checkpointTime time.Time
+		trusting       time.Duration
+		expErr         error
+	}{
+		{"recent checkpoint", now.Add(-time.Hour), trusting, nil},
+		{"stale checkpoint", now.Add(-trusting - time.Minute), trusting, ErrTrustingPeriodExpired},
+		{"zero trusting period", now, 0, ErrInvalidTrustingPeriod},
+		{"trusting period not lower than unbonding", now, unbonding, ErrInvalidTrustingPeriod},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trustedHeight := clienttypes.NewHeight(1, 5)
+			consensusState, err := NewConsensusState(uint64(tc.checkpointTime.UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+
+			clientState, err := CreateClientFromCheckpoint(testChainID, trustedHeight, consensusState, unbonding, tc.trusting, now)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, NewClientState(testChainID, uint64(trusting), uint64(unbonding), uint64(DefaultMaxClockDrift), trustedHeight), clientState)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, clientState)
+			}
+		})
+	}
+
+	_, err := CreateClientFromCheckpoint(testChainID, clienttypes.NewHeight(1, 5), nil, unbonding, trusting, now)
+	require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+}
+
+func TestValidateProofSpecs(t *testing.T) {
+	testCases := []struct {
+		name       string
+		proofSpecs []*ics23.ProofSpec
+		expErr     error
+	}{
+		{"valid two-spec set", []*ics23.ProofSpec{ics23.IavlSpec, ics23.TendermintSpec}, nil},
+		{"nil slice", nil, ErrInvalidProofSpecs},
+		{"empty slice", []*ics23.ProofSpec{}, ErrInvalidProofSpecs},
+		{"nil spec", []*ics23.ProofSpec{ics23.IavlSpec, nil}, ErrInvalidProofSpecs},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateProofSpecs(tc.proofSpecs)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestGetProofSpecs(t *testing.T) {
+	proofSpecs := newTestClientState().GetProofSpecs()
+	require.Equal(t, []*ics23.ProofSpec{ics23.IavlSpec, ics23.TendermintSpec}, proofSpecs)
+	require.NoError(t, validateProofSpecs(proofSpecs))
+}
+
+func TestGetLatestHeight(t *testing.T) {
+	clientState := newTestClientState()
+	require.Equal(t, clientState.LatestHeight, clientState.GetLatestHeight())
+
+	clientState.LatestHeight = clienttypes.NewHeight(1, 42)
+	require.Equal(t, clienttypes.NewHeight(1, 42), clientState.GetLatestHeight())
+}
+
+func TestNextExpectedHeight(t *testing.T) {
+	testCases := []struct {
+		name         string
+		latestHeight clienttypes.Height
+		expHeight    clienttypes.Height
+	}{
+		{"revision zero", clienttypes.NewHeight(0, 5), clienttypes.NewHeight(0, 6)},
+		{"non-zero revision", clienttypes.NewHeight(1, 42), clienttypes.NewHeight(1, 43)},
+		{"first height of an upgraded revision", clienttypes.NewHeight(2, 1), clienttypes.NewHeight(2, 2)},
+		{"large revision number", clienttypes.NewHeight(math.MaxUint64, 7), clienttypes.NewHeight(math.MaxUint64, 8)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState := newTestClientState()
+			clientState.LatestHeight = tc.latestHeight
+
+			nextHeight := clientState.NextExpectedHeight()
+			require.Equal(t, tc.expHeight, nextHeight)
+			require.Equal(t, tc.latestHeight.RevisionNumber, nextHeight.RevisionNumber)
+			require.Equal(t, tc.latestHeight.RevisionHeight+1, nextHeight.RevisionHeight)
+			require.True(t, nextHeight.GT(clientState.LatestHeight))
+			require.Equal(t, tc.latestHeight, clientState.LatestHeight)
+		})
+	}
+}
+
+func TestVerifyDelayPeriodPassed(t *testing.T) {
+	proofHeight := clienttypes.NewHeight(1, 5)
+	// the consensus state was processed at height 10 and time 100
+	const (
+		processedBlock = 10
+		processedTime  = 100
+	)
+
+	testCases := []struct {
+		name             string
+		blockHeight      int64
+		blockTime        int64
+		delayTimePeriod  uint64
+		delayBlockPeriod uint64
+		expErr           error
+	}{
+		{"no delay", processedBlock, processedTime, 0, 0, nil},
+		{"time delay satisfied", processedBlock, processedTime + 50, 50, 0, nil},
+		{"time delay not yet satisfied", processedBlock, processedTime + 49, 50, 0, ErrDelayPeriodNotPassed},
+		{"block delay satisfied", processedBlock + 3, processedTime, 0, 3, nil},
+		{"block delay not yet satisfied", processedBlock + 2, processedTime, 0, 3, ErrDelayPeriodNotPassed},
+		{"both delays satisfied", processedBlock + 3, processedTime + 50, 50, 3, nil},
+		{"time delay satisfied but not block delay", processedBlock + 2, processedTime + 50, 50, 3, ErrDelayPeriodNotPassed},
+		{"block delay satisfied but not time delay", processedBlock + 3, processedTime + 49, 50, 3, ErrDelayPeriodNotPassed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientStore := newTestClientStore()
+			SetProcessedTime(clientStore, proofHeight, processedTime)
+			SetProcessedHeight(clientStore, proofHeight, clienttypes.NewHeight(1, processedBlock))
+
+			ctx := newTestContext(testChainID, tc.blockHeight).WithBlockTime(time.Unix(0, tc.blockTime))
+			err := VerifyDelayPeriodPassed(ctx, clientStore, proofHeight, tc.delayTimePeriod, tc.delayBlockPeriod)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+
+	ctx := newTestContext(testChainID, processedBlock)
+	require.ErrorIs(t, VerifyDelayPeriodPassed(ctx, newTestClientStore(), proofHeight, 1, 0), ErrProcessedTimeNotFound)
+	require.ErrorIs(t, VerifyDelayPeriodPassed(ctx, newTestClientStore(), proofHeight, 0, 1), ErrProcessedHeightNotFound)
+}
@@ -1,11 +1,23 @@
 package cometbls
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	errorsmod "cosmossdk.io/errors"
+	sdkmath "cosmossdk.io/math"
 
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	cmttypes "github.com/cometbft/cometbft/types"
+
+	"github.com/cosmos/cosmos-sdk/telemetry"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 
@@ -19,94 +31,548 @@ var _ clienttypes.ConsensusHost = (*ConsensusHost)(nil)
 
 // ConsensusHost implements the 02-client clienttypes.ConsensusHost interface.
 type ConsensusHost struct {
-	stakingKeeper StakingKeeper
+	stakingKeeper    StakingKeeper
+	trustingPeriodFn TrustingPeriodFn
+
+	// revisionChainID and revision memoize the last parsed chain ID revision,
+	// guarded by revisionMu as the host may be shared by concurrent callers.
+	revisionMu      sync.RWMutex
+	revisionChainID string
+	revision        uint64
+
+	// ubdPeriodHeight and ubdPeriod memoize the unbonding time read from the staking keeper at the last
+	// block height, guarded by ubdPeriodMu. A zero ubdPeriodHeight means nothing is cached.
+	ubdPeriodMu     sync.Mutex
+	ubdPeriodHeight int64
+	ubdPeriod       time.Duration
+
+	// fallbackLimit is the number of heights GetSelfConsensusStateOrEarlier may walk back.
+	fallbackLimit uint64
 }
 
+// DefaultSelfConsensusStateFallbackLimit is the default number of heights GetSelfConsensusStateOrEarlier may walk
+// back from the requested height, see WithSelfConsensusStateFallbackLimit.
+const DefaultSelfConsensusStateFallbackLimit uint64 = 100
+
 // StakingKeeper defines an expected interface for the tendermint ConsensusHost.
 type StakingKeeper interface {
 	GetHistoricalInfo(ctx context.Context, height int64) (stakingtypes.HistoricalInfo, error)
 	UnbondingTime(ctx context.Context) (time.Duration, error)
 }
 
+// HistoricalEntriesKeeper is implemented by staking keepers exposing the number of historical entries they retain,
+// such as the x/staking keeper.
+type HistoricalEntriesKeeper interface {
+	HistoricalEntries(ctx context.Context) (uint32, error)
+}
+
+// PowerReductionKeeper is implemented by staking keepers exposing the number of tokens per unit of consensus power
+// of the chain, such as the x/staking keeper. The SDK default power reduction is assumed otherwise.
+type PowerReductionKeeper interface {
+	PowerReduction(ctx context.Context) sdkmath.Int
+}
+
+// TrustingPeriodFn returns the trusting period self clients are expected to be configured with.
+type TrustingPeriodFn func(ctx sdk.Context) (time.Duration, error)
+
+// ConsensusHostOption configures optional ConsensusHost parameters.
+type ConsensusHostOption func(*ConsensusHost)
+
+// WithTrustingPeriodFn makes ValidateSelfClient require the client trusting period to match the one returned
+// by fn, e.g. a governance parameter. By default, the trusting period is only bounded by the unbonding period.
+func WithTrustingPeriodFn(fn TrustingPeriodFn) ConsensusHostOption {
+	return func(c *ConsensusHost) {
+		c.trustingPeriodFn = fn
+	}
+}
+
+// WithStakingKeeper replaces the staking keeper the ConsensusHost was constructed with, e.g. with a mock in tests.
+func WithStakingKeeper(k StakingKeeper) ConsensusHostOption {
+	return func(c *ConsensusHost) {
+		c.stakingKeeper = k
+	}
+}
+
+// WithSelfConsensusStateFallbackLimit sets the number of heights GetSelfConsensusStateOrEarlier may walk back from
+// the requested height when historical info is missing. A zero limit disables the fallback.
+func WithSelfConsensusStateFallbackLimit(limit uint64) ConsensusHostOption {
+	return func(c *ConsensusHost) {
+		c.fallbackLimit = limit
+	}
+}
+
 // NewConsensusHost creates and returns a new ConsensusHost for tendermint consensus.
-func NewConsensusHost(stakingKeeper clienttypes.StakingKeeper) clienttypes.ConsensusHost {
-	return &ConsensusHost{
+func NewConsensusHost(stakingKeeper clienttypes.StakingKeeper, opts ...ConsensusHostOption) clienttypes.ConsensusHost {
+	host := &ConsensusHost{
 		stakingKeeper: stakingKeeper,
+		fallbackLimit: DefaultSelfConsensusStateFallbackLimit,
+	}
+	for _, opt := range opts {
+		opt(host)
 	}
+	return host
 }
 
 // GetSelfConsensusState implements the 02-client clienttypes.ConsensusHost interface.
-func (c *ConsensusHost) GetSelfConsensusState(ctx sdk.Context, height exported.Height) (exported.ConsensusState, error) {
+//
+// The historical info is trusted to be consistent as it is written by the staking module of this chain: its
+// validator set is not hashed again against the header next validators hash, which dominates the cost of
+// the call for large validator sets. Use GetSelfConsensusStateStrict to perform this check as well.
+func (c *ConsensusHost) GetSelfConsensusState(ctx sdk.Context, height exported.Height) (_ exported.ConsensusState, err error) {
+	defer func(start time.Time) {
+		emitTelemetry("get_self_consensus_state", KindSelf, ctx.ChainID(), start, err)
+	}(telemetry.Now())
+
+	revision, err := c.parseChainID(ctx.ChainID())
+	if err != nil {
+		return nil, err
+	}
+	return c.getSelfConsensusState(ctx, revision, height, false)
+}
+
+// GetSelfConsensusStateStrict returns the self consensus state at the given height as GetSelfConsensusState does,
+// but also requires the historical info to hold the validator set its header next validators hash commits to.
+// It should be preferred whenever the historical info may not have been written by the staking module, e.g.
+// after a migration of its store, which may have dropped the validator set, at the cost of hashing the whole
+// validator set.
+func (c *ConsensusHost) GetSelfConsensusStateStrict(ctx sdk.Context, height exported.Height) (_ exported.ConsensusState, err error) {
+	defer func(start time.Time) {
+		emitTelemetry("get_self_consensus_state_strict", KindSelf, ctx.ChainID(), start, err)
+	}(telemetry.Now())
+
+	revision, err := c.parseChainID(ctx.ChainID())
+	if err != nil {
+		return nil, err
+	}
+	return c.getSelfConsensusState(ctx, revision, height, true)
+}
+
+// GetSelfConsensusStateOrEarlier returns the self consensus state at the given height as GetSelfConsensusState does
+// or, if its historical info is unavailable, e.g. pruned, the one at the nearest earlier height with available
+// historical info, along with the height actually used. It walks back at most the configured fallback limit of
+// heights, see WithSelfConsensusStateFallbackLimit, and never past the first height of the revision.
+func (c *ConsensusHost) GetSelfConsensusStateOrEarlier(ctx sdk.Context, height exported.Height) (_ exported.ConsensusState, _ exported.Height, err error) {
+	defer func(start time.Time) {
+		emitTelemetry("get_self_consensus_state_or_earlier", KindSelf, ctx.ChainID(), start, err)
+	}(telemetry.Now())
+
+	selfHeight, ok := height.(clienttypes.Height)
+	if !ok {
+		return nil, nil, errorsmod.Wrapf(ibcerrors.ErrInvalidType, "expected %T, got %T", clienttypes.Height{}, height)
+	}
+
+	revision, err := c.parseChainID(ctx.ChainID())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for walked := uint64(0); ; walked++ {
+		consensusState, err := c.getSelfConsensusState(ctx, revision, selfHeight, false)
+		if err == nil {
+			return consensusState, selfHeight, nil
+		}
+		if !errors.Is(err, ErrHistoricalInfoUnavailable) {
+			return nil, nil, err
+		}
+		if walked == c.fallbackLimit || selfHeight.RevisionHeight <= 1 {
+			return nil, nil, errorsmod.Wrapf(ErrHistoricalInfoUnavailable, "no historical info within %d heights below height %s",
+				walked, height)
+		}
+		selfHeight = clienttypes.NewHeight(selfHeight.RevisionNumber, selfHeight.RevisionHeight-1)
+	}
+}
+
+// getSelfConsensusState returns the self consensus state at the given height of the given chain revision,
+// requiring the historical info to hold a validator set and recomputing its hash if strict is set.
+func (c *ConsensusHost) getSelfConsensusState(ctx sdk.Context, revision uint64, height exported.Height, strict bool) (exported.ConsensusState, error) {
 	selfHeight, ok := height.(clienttypes.Height)
 	if !ok {
 		return nil, errorsmod.Wrapf(ibcerrors.ErrInvalidType, "expected %T, got %T", clienttypes.Height{}, height)
 	}
 
 	// check that height revision matches chainID revision
-	revision := clienttypes.ParseChainID(ctx.ChainID())
-	if revision != height.GetRevisionNumber() {
-		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "chainID revision number does not match height revision number: expected %d, got %d", revision, height.GetRevisionNumber())
+	if err := checkRevision(revision, height); err != nil {
+		return nil, err
+	}
+
+	// the staking keeper indexes historical info by int64 heights
+	if selfHeight.RevisionHeight > math.MaxInt64 {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "height %d overflows an int64", selfHeight.RevisionHeight)
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	// historical info is only retained for the most recent heights, reject heights outside of this window
+	// before hitting the keeper
+	if _, ok := c.stakingKeeper.(HistoricalEntriesKeeper); ok {
+		lookback, err := c.GetMaxHistoricalLookback(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		oldestHeight := ctx.BlockHeight() - lookback + 1
+		if h := int64(selfHeight.RevisionHeight); h < oldestHeight || h > ctx.BlockHeight() {
+			return nil, errorsmod.Wrapf(ErrHistoricalInfoUnavailable, "height %d is outside of the retained window [%d, %d]",
+				h, oldestHeight, ctx.BlockHeight())
+		}
 	}
 
 	histInfo, err := c.stakingKeeper.GetHistoricalInfo(ctx, int64(selfHeight.RevisionHeight))
+	if errors.Is(err, stakingtypes.ErrNoHistoricalInfo) {
+		return nil, errorsmod.Wrapf(ErrHistoricalInfoUnavailable, "height %d", selfHeight.RevisionHeight)
+	}
 	if err != nil {
 		return nil, errorsmod.Wrapf(err, "height %d", selfHeight.RevisionHeight)
 	}
 
-	consensusState := &ConsensusState{
-		Timestamp:          uint64(histInfo.Header.Time.UnixNano()),
-		Root:               commitmenttypes.NewMerkleRoot(histInfo.Header.GetAppHash()),
-		NextValidatorsHash: histInfo.Header.NextValidatorsHash,
+	// the consensus state must be the one of the requested height, whatever the keeper returns
+	if histInfo.Header.Height != int64(selfHeight.RevisionHeight) {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "historical info header height does not match requested height: expected %d, got %d",
+			selfHeight.RevisionHeight, histInfo.Header.Height)
+	}
+
+	// old or specially configured chains may not have committed to a next validator set, the resulting
+	// consensus state could not be used to verify any validator set
+	if len(histInfo.Header.NextValidatorsHash) == 0 {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "historical info next validators hash cannot be empty at height %d",
+			selfHeight.RevisionHeight)
+	}
+
+	// the historical info validator set is the one committed by the header next validators hash
+	if strict {
+		if len(histInfo.Valset) == 0 {
+			return nil, errorsmod.Wrapf(ErrInvalidValidatorSet, "historical info validator set cannot be empty at height %d",
+				selfHeight.RevisionHeight)
+		}
+
+		nextValsHash, err := validatorSetHash(histInfo.Valset, c.powerReduction(ctx))
+		if err != nil {
+			return nil, errorsmod.Wrapf(err, "height %d", selfHeight.RevisionHeight)
+		}
+		if !bytes.Equal(nextValsHash, histInfo.Header.NextValidatorsHash) {
+			return nil, errorsmod.Wrapf(ErrInvalidValidatorSet, "historical info validator set hash does not match header next validators hash at height %d: expected %X, got %X",
+				selfHeight.RevisionHeight, histInfo.Header.NextValidatorsHash, nextValsHash)
+		}
+	}
+
+	// an empty or truncated app hash would yield a root no membership proof can be verified against
+	if len(histInfo.Header.AppHash) != tmhash.Size {
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "app hash must be %d bytes at height %d, got: %d",
+			tmhash.Size, selfHeight.RevisionHeight, len(histInfo.Header.AppHash))
+	}
+
+	consensusState, err := NewConsensusState(
+		uint64(histInfo.Header.Time.UnixNano()),
+		commitmenttypes.NewMerkleRoot(histInfo.Header.GetAppHash()),
+		histInfo.Header.NextValidatorsHash,
+	)
+	if err != nil {
+		return nil, errorsmod.Wrapf(err, "height %d", selfHeight.RevisionHeight)
 	}
 
 	return consensusState, nil
 }
 
+// GetMaxHistoricalLookback returns the number of most recent heights the staking keeper retains historical info for,
+// as set by the HistoricalEntries staking parameter.
+func (c *ConsensusHost) GetMaxHistoricalLookback(ctx sdk.Context) (int64, error) {
+	keeper, ok := c.stakingKeeper.(HistoricalEntriesKeeper)
+	if !ok {
+		return 0, errorsmod.Wrapf(ibcerrors.ErrInvalidType, "staking keeper %T does not expose historical entries", c.stakingKeeper)
+	}
+
+	entries, err := keeper.HistoricalEntries(ctx)
+	if err != nil {
+		return 0, errorsmod.Wrap(err, "failed to retrieve historical entries")
+	}
+
+	return int64(entries), nil
+}
+
+// BatchGetSelfConsensusState returns the self consensus states at each of the given heights, in input order.
+// The chain ID revision is parsed once for the whole batch. It stops at the first failing height, which is
+// annotated on the returned error.
+func (c *ConsensusHost) BatchGetSelfConsensusState(ctx sdk.Context, heights []exported.Height) (_ []exported.ConsensusState, err error) {
+	defer func(start time.Time) {
+		emitTelemetry("batch_get_self_consensus_state", KindSelf, ctx.ChainID(), start, err)
+	}(telemetry.Now())
+
+	revision, err := c.parseChainID(ctx.ChainID())
+	if err != nil {
+		return nil, err
+	}
+
+	consensusStates := make([]exported.ConsensusState, len(heights))
+	for i, height := range heights {
+		consensusState, err := c.getSelfConsensusState(ctx, revision, height, false)
+		if err != nil {
+			return nil, errorsmod.Wrapf(err, "batch height %s at index %d", height, i)
+		}
+		consensusStates[i] = consensusState
+	}
+
+	return consensusStates, nil
+}
+
+// powerReduction returns the power reduction of the staking keeper, or the SDK default power reduction if the keeper
+// does not expose it.
+func (c *ConsensusHost) powerReduction(ctx sdk.Context) sdkmath.Int {
+	if keeper, ok := c.stakingKeeper.(PowerReductionKeeper); ok {
+		return keeper.PowerReduction(ctx)
+	}
+	return sdk.DefaultPowerReduction
+}
+
+// unbondingTime returns the unbonding time of the staking keeper. The unbonding time is assumed not to change within
+// a block, it is only read once per block height so that batch validations do not repeatedly hit the keeper.
+// Keeper errors are not cached.
+func (c *ConsensusHost) unbondingTime(ctx sdk.Context) (time.Duration, error) {
+	c.ubdPeriodMu.Lock()
+	defer c.ubdPeriodMu.Unlock()
+
+	if c.ubdPeriodHeight != 0 && c.ubdPeriodHeight == ctx.BlockHeight() {
+		return c.ubdPeriod, nil
+	}
+
+	ubdPeriod, err := c.stakingKeeper.UnbondingTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c.ubdPeriodHeight = ctx.BlockHeight()
+	c.ubdPeriod = ubdPeriod
+	return ubdPeriod, nil
+}
+
 // ValidateSelfClient implements the 02-client clienttypes.ConsensusHost interface.
-func (c *ConsensusHost) ValidateSelfClient(ctx sdk.Context, clientState exported.ClientState) error {
+func (c *ConsensusHost) ValidateSelfClient(ctx sdk.Context, clientState exported.ClientState) (err error) {
+	defer func(start time.Time) {
+		if err != nil {
+			err = errorsmod.Wrapf(err, "client %s", GetClientID(ctx))
+		}
+		emitTelemetry("validate_self_client", KindSelf, ctx.ChainID(), start, err)
+	}(telemetry.Now())
+
+	logger := ctx.Logger().With("module", "cometbls-client")
+
 	tmClient, ok := clientState.(*ClientState)
 	if !ok {
+		logger.Debug("rejected self client", "reason", "invalid client type", "expected", fmt.Sprintf("%T", &ClientState{}), "actual", fmt.Sprintf("%T", clientState))
 		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "client must be a Tendermint client, expected: %T, got: %T", &ClientState{}, tmClient)
 	}
 
-	if !tmClient.FrozenHeight.IsZero() {
+	if err := CheckNotFrozen(tmClient); err != nil {
+		logger.Debug("rejected self client", "reason", "client is frozen", "frozen_height", tmClient.FrozenHeight)
+		return err
+	}
+
+	if err := validateChainID(tmClient.ChainId); err != nil {
+		logger.Debug("rejected self client", "reason", "invalid chain-id format", "chain_id", tmClient.ChainId)
+		return err
+	}
+
+	if err := CheckChainIDMatch(ctx.ChainID(), tmClient.ChainId); err != nil {
+		logger.Debug("rejected self client", "reason", "invalid chain-id", "expected", ctx.ChainID(), "actual", tmClient.ChainId)
+		return err
+	}
+
+	// client must be in the same revision as executing chain
+	if err := c.checkRevisionMatch(ctx.ChainID(), tmClient.GetLatestHeight()); err != nil {
+		logger.Debug("rejected self client", "reason", "invalid revision", "chain_id", ctx.ChainID(), "actual", tmClient.LatestHeight.RevisionNumber)
+		return errorsmod.Wrap(err, "client is not in the same revision as the chain")
+	}
+
+	// there is no self height to bound the client latest height with before the first block, e.g. at genesis
+	if ctx.BlockHeight() <= 0 {
+		logger.Debug("rejected self client", "reason", "non-positive chain height", "chain_height", ctx.BlockHeight())
+		return errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "chain height must be positive to validate a self client, got: %d", ctx.BlockHeight())
+	}
+
+	selfHeight := clienttypes.NewHeight(tmClient.LatestHeight.RevisionNumber, uint64(ctx.BlockHeight()))
+	if err := CheckLatestHeightBound(tmClient.LatestHeight, selfHeight); err != nil {
+		logger.Debug("rejected self client", "reason", "invalid latest height", "chain_height", selfHeight, "latest_height", tmClient.LatestHeight)
+		return err
+	}
+
+	if err := CheckPeriods(tmClient.UnbondingPeriod, tmClient.TrustingPeriod); err != nil {
+		logger.Debug("rejected self client", "reason", "invalid periods",
+			"unbonding_period", tmClient.GetUnbondingDuration(), "trusting_period", tmClient.GetTrustingDuration())
+		return err
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	expectedUbdPeriod, err := c.unbondingTime(ctx)
+	if err != nil {
+		return errorsmod.Wrapf(err, "failed to retrieve unbonding period")
+	}
+
+	if tmClient.GetUnbondingDuration() != expectedUbdPeriod {
+		logger.Debug("rejected self client", "reason", "invalid unbonding period", "expected", expectedUbdPeriod, "actual", tmClient.GetUnbondingDuration())
+		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "invalid unbonding period. expected: %s, got: %s",
+			expectedUbdPeriod, tmClient.GetUnbondingDuration())
+	}
+
+	if c.trustingPeriodFn != nil {
+		expectedTrustingPeriod, err := c.trustingPeriodFn(ctx)
+		if err != nil {
+			return errorsmod.Wrapf(err, "failed to retrieve trusting period")
+		}
+
+		if tmClient.GetTrustingDuration() != expectedTrustingPeriod {
+			logger.Debug("rejected self client", "reason", "invalid trusting period", "expected", expectedTrustingPeriod, "actual", tmClient.GetTrustingDuration())
+			return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "invalid trusting period. expected: %s, got: %s",
+				expectedTrustingPeriod, tmClient.GetTrustingDuration())
+		}
+	}
+
+	return nil
+}
+
+// CheckNotFrozen returns an ErrClientFrozen if the client state has been frozen.
+func CheckNotFrozen(clientState *ClientState) error {
+	if !clientState.FrozenHeight.IsZero() {
 		return clienttypes.ErrClientFrozen
 	}
+	return nil
+}
 
-	if ctx.ChainID() != tmClient.ChainId {
+// CheckChainIDMatch returns an ErrInvalidClient if the chain ID of a client does not match the expected one.
+func CheckChainIDMatch(expected, actual string) error {
+	if expected != actual {
 		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "invalid chain-id. expected: %s, got: %s",
-			ctx.ChainID(), tmClient.ChainId)
+			expected, actual)
 	}
+	return nil
+}
 
-	revision := clienttypes.ParseChainID(ctx.ChainID())
+// CheckRevisionMatch returns an error if the revision number of the height does not match the
+// revision number of the chain ID.
+func CheckRevisionMatch(chainID string, height exported.Height) error {
+	revision, err := parseChainIDRevision(chainID)
+	if err != nil {
+		return err
+	}
+	return checkRevision(revision, height)
+}
 
-	// client must be in the same revision as executing chain
-	if tmClient.LatestHeight.RevisionNumber != revision {
-		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "client is not in the same revision as the chain. expected revision: %d, got: %d",
-			tmClient.LatestHeight.RevisionNumber, revision)
+// NeedsRevisionUpgrade returns whether the revision number of the chain ID of the context is higher than the revision
+// number of the self client latest height, along with the chain revision number. Such a client is rejected by
+// ValidateSelfClient until it is upgraded to the new revision. No upgrade is reported for an invalid chain ID.
+func NeedsRevisionUpgrade(ctx sdk.Context, clientState *ClientState) (bool, uint64) {
+	revision, err := parseChainIDRevision(ctx.ChainID())
+	if err != nil {
+		return false, 0
 	}
+	return revision > clientState.LatestHeight.RevisionNumber, revision
+}
 
-	selfHeight := clienttypes.NewHeight(revision, uint64(ctx.BlockHeight()))
-	if tmClient.LatestHeight.GTE(selfHeight) {
+// CheckLatestHeightBound returns an ErrInvalidClient if the latest height of a client is zero or not lower than
+// the height of the chain it tracks.
+func CheckLatestHeightBound(latestHeight, selfHeight clienttypes.Height) error {
+	// a zero revision height would be lower than any chain height but has no consensus state to be looked up
+	if latestHeight.RevisionHeight == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidClient, "client latest height revision height cannot be zero")
+	}
+	if latestHeight.GTE(selfHeight) {
 		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "client has LatestHeight %d greater than or equal to chain height %d",
-			tmClient.LatestHeight, selfHeight)
+			latestHeight, selfHeight)
+	}
+	return nil
+}
+
+// CheckPeriods returns an ErrInvalidClient if either period is zero or the unbonding period is not greater than
+// the trusting period, as ClientState.ValidateBasic does. Both periods are in nanoseconds.
+func CheckPeriods(unbondingPeriod, trustingPeriod uint64) error {
+	if unbondingPeriod == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidClient, "unbonding period cannot be zero")
+	}
+	if trustingPeriod == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidClient, "trusting period cannot be zero")
+	}
+	if unbondingPeriod <= trustingPeriod {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "unbonding period must be greater than trusting period. unbonding period (%d) <= trusting period (%d)",
+			unbondingPeriod, trustingPeriod)
+	}
+	return nil
+}
+
+// validateChainID returns an ErrInvalidClient if the chain ID is empty or longer than allowed by CometBFT.
+func validateChainID(chainID string) error {
+	if strings.TrimSpace(chainID) == "" {
+		return errorsmod.Wrap(clienttypes.ErrInvalidClient, "chain-id cannot be empty")
 	}
+	if len(chainID) > cmttypes.MaxChainIDLen {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "chain-id is too long. max: %d, got: %d", cmttypes.MaxChainIDLen, len(chainID))
+	}
+	return nil
+}
+
+// checkContext returns an ErrContextDone if the context has been cancelled or its deadline exceeded, so that
+// cancelled batch verifications do not keep hitting the staking keeper.
+func checkContext(ctx sdk.Context) error {
+	if err := ctx.Context().Err(); err != nil {
+		return errorsmod.Wrap(ErrContextDone, err.Error())
+	}
+	return nil
+}
 
-	expectedUbdPeriod, err := c.stakingKeeper.UnbondingTime(ctx)
+// parseChainID returns the revision number of the given chain ID. The result is
+// cached until a different chain ID is provided, e.g. after an upgrade.
+func (c *ConsensusHost) parseChainID(chainID string) (uint64, error) {
+	c.revisionMu.RLock()
+	if chainID == c.revisionChainID {
+		defer c.revisionMu.RUnlock()
+		return c.revision, nil
+	}
+	c.revisionMu.RUnlock()
+
+	revision, err := parseChainIDRevision(chainID)
 	if err != nil {
-		return errorsmod.Wrapf(err, "failed to retrieve unbonding period")
+		return 0, err
 	}
 
-	// TODO(aeryz): verify if this is nanoseconds
-	if uint64(expectedUbdPeriod.Nanoseconds()) != tmClient.UnbondingPeriod {
-		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "invalid unbonding period. expected: %d, got: %d",
-			expectedUbdPeriod, tmClient.UnbondingPeriod)
+	c.revisionMu.Lock()
+	defer c.revisionMu.Unlock()
+	c.revisionChainID = chainID
+	c.revision = revision
+	return revision, nil
+}
+
+// parseChainIDRevision returns the revision number of the given chain ID as clienttypes.ParseChainID does,
+// but returns an ErrInvalidChainID instead of panicking if the revision number overflows a uint64.
+func parseChainIDRevision(chainID string) (uint64, error) {
+	if !clienttypes.IsRevisionFormat(chainID) {
+		// chainID is not in revision format, return 0 as default
+		return 0, nil
+	}
+
+	splitStr := strings.Split(chainID, "-")
+	revision, err := strconv.ParseUint(splitStr[len(splitStr)-1], 10, 64)
+	if err != nil {
+		return 0, errorsmod.Wrapf(ErrInvalidChainID, "invalid revision number for chain-id %s: %s", chainID, err)
 	}
+	return revision, nil
+}
 
-	if tmClient.UnbondingPeriod < tmClient.TrustingPeriod {
-		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "unbonding period must be greater than trusting period. unbonding period (%d) < trusting period (%d)",
-			tmClient.UnbondingPeriod, tmClient.TrustingPeriod)
+// checkRevisionMatch returns an error if the revision number of the height does not match the
+// revision number of the chain ID.
+func (c *ConsensusHost) checkRevisionMatch(chainID string, height exported.Height) error {
+	revision, err := c.parseChainID(chainID)
+	if err != nil {
+		return err
 	}
+	return checkRevision(revision, height)
+}
 
+// checkRevision returns an ErrInvalidHeight if the revision number of the height does not match the given revision.
+func checkRevision(revision uint64, height exported.Height) error {
+	if revision != height.GetRevisionNumber() {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "chainID revision number does not match height revision number: expected %d, got %d", revision, height.GetRevisionNumber())
+	}
 	return nil
 }
@@ -0,0 +1,1206 @@
+package cometbls
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	sdkmath "cosmossdk.io/math"
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const testChainID = "union-devnet-1"
+
+type mockStakingKeeper struct {
+	histInfo     stakingtypes.HistoricalInfo
+	histInfoErr  error
+	ubdPeriod    time.Duration
+	ubdPeriodErr error
+}
+
+func (m mockStakingKeeper) GetHistoricalInfo(_ context.Context, _ int64) (stakingtypes.HistoricalInfo, error) {
+	return m.histInfo, m.histInfoErr
+}
+
+func (m mockStakingKeeper) UnbondingTime(_ context.Context) (time.Duration, error) {
+	return m.ubdPeriod, m.ubdPeriodErr
+}
+
+func newTestHistoricalInfo(height int64) stakingtypes.HistoricalInfo {
+	return stakingtypes.HistoricalInfo{
+		Header: cmtproto.Header{
+			ChainID:            testChainID,
+			Height:             height,
+			Time:               time.Unix(1710783278, 0),
+			AppHash:            testAppHash,
+			NextValidatorsHash: testNextValidatorsHash,
+		},
+	}
+}
+
+func newTestContext(chainID string, height int64) sdk.Context {
+	return sdk.NewContext(nil, cmtproto.Header{
+		ChainID: chainID,
+		Height:  height,
+		Time:    time.Unix(1710783278, 0),
+	}, false, log.NewNopLogger())
+}
+
+func TestValidateSelfClientUnbondingPeriod(t *testing.T) {
+	const ubdPeriod = 21 * 24 * time.Hour
+
+	testCases := []struct {
+		name            string
+		unbondingPeriod uint64
+		stakingPeriod   time.Duration
+		expPass         bool
+	}{
+		{"matching unbonding period", uint64(ubdPeriod), ubdPeriod, true},
+		{"unbonding period in seconds", uint64(ubdPeriod / time.Second), ubdPeriod, false},
+		{"mismatched unbonding period", uint64(ubdPeriod), ubdPeriod + time.Second, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(mockStakingKeeper{ubdPeriod: tc.stakingPeriod})
+			clientState := NewClientState(
+				testChainID, uint64(ubdPeriod/2), tc.unbondingPeriod, uint64(time.Minute),
+				clienttypes.NewHeight(1, 5),
+			)
+
+			err := host.ValidateSelfClient(newTestContext(testChainID, 10), clientState)
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+			}
+		})
+	}
+}
+
+func TestParseChainIDCache(t *testing.T) {
+	host := &ConsensusHost{}
+
+	requireRevision := func(expected uint64, chainID string) {
+		t.Helper()
+
+		revision, err := host.parseChainID(chainID)
+		require.NoError(t, err)
+		require.Equal(t, expected, revision)
+	}
+
+	requireRevision(1, "union-devnet-1")
+	requireRevision(1, "union-devnet-1")
+
+	// upgrade bumping the revision
+	requireRevision(2, "union-devnet-2")
+	requireRevision(0, "union")
+	requireRevision(0, "")
+
+	// overflowing revisions are rejected and not cached
+	_, err := host.parseChainID("union-devnet-18446744073709551616")
+	require.ErrorIs(t, err, ErrInvalidChainID)
+	require.Equal(t, "", host.revisionChainID)
+}
+
+func BenchmarkParseChainID(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			clienttypes.ParseChainID(testChainID)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		host := &ConsensusHost{}
+		for i := 0; i < b.N; i++ {
+			host.parseChainID(testChainID)
+		}
+	})
+}
+
+func BenchmarkGetSelfConsensusState(b *testing.B) {
+	valSet, valSetHash := newTestValidatorSet(b, 100)
+	histInfo := newTestHistoricalInfo(5)
+	histInfo.Valset = valSet
+	histInfo.Header.NextValidatorsHash = valSetHash
+
+	ctx := newTestContext(testChainID, 10)
+	height := clienttypes.NewHeight(1, 5)
+
+	b.Run("uncached chain-id", func(b *testing.B) {
+		host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo}).(*ConsensusHost)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			host.revisionChainID = ""
+			if _, err := host.GetSelfConsensusState(ctx, height); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached chain-id", func(b *testing.B) {
+		host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := host.GetSelfConsensusState(ctx, height); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("strict", func(b *testing.B) {
+		host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo}).(*ConsensusHost)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := host.GetSelfConsensusStateStrict(ctx, height); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetSelfConsensusStateHistoricalInfo(t *testing.T) {
+	errKeeper := errors.New("keeper failure")
+
+	testCases := []struct {
+		name        string
+		histInfoErr error
+		expErr      error
+	}{
+		{"historical info found", nil, nil},
+		{"historical info pruned", stakingtypes.ErrNoHistoricalInfo, ErrHistoricalInfoUnavailable},
+		{"keeper failure", errKeeper, errKeeper},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(mockStakingKeeper{
+				histInfo:    newTestHistoricalInfo(5),
+				histInfoErr: tc.histInfoErr,
+			})
+
+			consensusState, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, 5))
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.NotNil(t, consensusState)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				if tc.expErr != ErrHistoricalInfoUnavailable {
+					require.NotErrorIs(t, err, ErrHistoricalInfoUnavailable)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSelfConsensusStateHistoricalInfoHeight(t *testing.T) {
+	testCases := []struct {
+		name         string
+		headerHeight int64
+		expErr       error
+	}{
+		{"header at the requested height", 5, nil},
+		{"header below the requested height", 4, clienttypes.ErrInvalidHeight},
+		{"header above the requested height", 6, clienttypes.ErrInvalidHeight},
+		{"zero header height", 0, clienttypes.ErrInvalidHeight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the keeper returns the same historical info whatever the requested height
+			host := NewConsensusHost(mockStakingKeeper{histInfo: newTestHistoricalInfo(tc.headerHeight)})
+
+			consensusState, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, 5))
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.NotNil(t, consensusState)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, consensusState)
+			}
+		})
+	}
+}
+
+func newTestValidatorSet(t testing.TB, n int) ([]stakingtypes.Validator, []byte) {
+	t.Helper()
+
+	validators := make([]stakingtypes.Validator, n)
+	for i := range validators {
+		validators[i] = newTestValidator(int64(i+1), sdk.TokensFromConsensusPower(int64(i+1), sdk.DefaultPowerReduction))
+	}
+
+	valSetHash, err := validatorSetHash(validators, sdk.DefaultPowerReduction)
+	require.NoError(t, err)
+	return validators, valSetHash
+}
+
+// newTestValidator returns a bonded CometBLS validator with the given secret key and tokens.
+func newTestValidator(secret int64, tokens sdkmath.Int) stakingtypes.Validator {
+	_, _, g1, _ := curve.Generators()
+	var pubkey curve.G1Affine
+	pubkey.ScalarMultiplication(&g1, big.NewInt(secret))
+	key := pubkey.Bytes()
+
+	return stakingtypes.Validator{
+		OperatorAddress: sdk.ValAddress(tmhash.SumTruncated(key[:])).String(),
+		ConsensusPubkey: newTestBn254PubKey(key[:]),
+		Status:          stakingtypes.Bonded,
+		Tokens:          tokens,
+	}
+}
+
+// newTestBn254PubKey returns the consensus public key of a CometBLS validator with the given compressed key.
+func newTestBn254PubKey(key []byte) *codectypes.Any {
+	value := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return &codectypes.Any{TypeUrl: Bn254PubKeyTypeURL, Value: protowire.AppendBytes(value, key)}
+}
+
+func TestGetSelfConsensusStateValidatorSet(t *testing.T) {
+	valSet, valSetHash := newTestValidatorSet(t, 3)
+
+	testCases := []struct {
+		name         string
+		valSet       []stakingtypes.Validator
+		nextValsHash []byte
+		expFast      error
+		expStrict    error
+	}{
+		{"matching validator set", valSet, valSetHash, nil, nil},
+		{"no validator set", nil, testNextValidatorsHash, nil, ErrInvalidValidatorSet},
+		{"mismatched validator set", valSet, testNextValidatorsHash, nil, ErrInvalidValidatorSet},
+		{"partial validator set", valSet[:2], valSetHash, nil, ErrInvalidValidatorSet},
+		{"reordered validator set", []stakingtypes.Validator{valSet[1], valSet[0], valSet[2]}, valSetHash, nil, ErrInvalidValidatorSet},
+		{"validator without power", append([]stakingtypes.Validator{newTestValidator(4, sdkmath.ZeroInt())}, valSet...), valSetHash, nil, ErrInsufficientVotingPower},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histInfo := newTestHistoricalInfo(5)
+			histInfo.Valset = tc.valSet
+			histInfo.Header.NextValidatorsHash = tc.nextValsHash
+
+			host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo}).(*ConsensusHost)
+			ctx := newTestContext(testChainID, 10)
+
+			_, err := host.GetSelfConsensusState(ctx, clienttypes.NewHeight(1, 5))
+			if tc.expFast == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expFast)
+			}
+
+			_, err = host.GetSelfConsensusStateStrict(ctx, clienttypes.NewHeight(1, 5))
+			if tc.expStrict == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expStrict)
+			}
+		})
+	}
+}
+
+// powerReductionStakingKeeper additionally exposes the power reduction of the chain.
+type powerReductionStakingKeeper struct {
+	mockStakingKeeper
+	powerReduction sdkmath.Int
+}
+
+func (k powerReductionStakingKeeper) PowerReduction(_ context.Context) sdkmath.Int {
+	return k.powerReduction
+}
+
+func TestGetSelfConsensusStatePowerReduction(t *testing.T) {
+	powerReduction := sdkmath.NewInt(1_000_000_000)
+
+	// the validators have a consensus power of 1, 2 and 3 with the power reduction of the chain
+	valSet := make([]stakingtypes.Validator, 3)
+	for i := range valSet {
+		valSet[i] = newTestValidator(int64(i+1), powerReduction.MulRaw(int64(i+1)))
+	}
+	valSetHash, err := validatorSetHash(valSet, powerReduction)
+	require.NoError(t, err)
+
+	histInfo := newTestHistoricalInfo(5)
+	histInfo.Valset = valSet
+	histInfo.Header.NextValidatorsHash = valSetHash
+	ctx := newTestContext(testChainID, 10)
+
+	host := NewConsensusHost(powerReductionStakingKeeper{mockStakingKeeper{histInfo: histInfo}, powerReduction}).(*ConsensusHost)
+	_, err = host.GetSelfConsensusState(ctx, clienttypes.NewHeight(1, 5))
+	require.NoError(t, err)
+	_, err = host.GetSelfConsensusStateStrict(ctx, clienttypes.NewHeight(1, 5))
+	require.NoError(t, err)
+
+	// the validator powers differ with the default power reduction
+	host = NewConsensusHost(mockStakingKeeper{histInfo: histInfo}).(*ConsensusHost)
+	_, err = host.GetSelfConsensusStateStrict(ctx, clienttypes.NewHeight(1, 5))
+	require.ErrorIs(t, err, ErrInvalidValidatorSet)
+}
+
+func TestGetSelfConsensusStateStrict(t *testing.T) {
+	valSet, valSetHash := newTestValidatorSet(t, 3)
+
+	testCases := []struct {
+		name      string
+		malleate  func(histInfo *stakingtypes.HistoricalInfo)
+		expFast   error
+		expStrict error
+	}{
+		{
+			"consistent historical info",
+			func(histInfo *stakingtypes.HistoricalInfo) {},
+			nil, nil,
+		},
+		{
+			"validator set dropped",
+			func(histInfo *stakingtypes.HistoricalInfo) {
+				histInfo.Valset = nil
+			},
+			nil, ErrInvalidValidatorSet,
+		},
+		{
+			"tampered validator power",
+			func(histInfo *stakingtypes.HistoricalInfo) {
+				histInfo.Valset[0].Tokens = histInfo.Valset[0].Tokens.MulRaw(2)
+			},
+			nil, ErrInvalidValidatorSet,
+		},
+		{
+			"validator removed",
+			func(histInfo *stakingtypes.HistoricalInfo) {
+				histInfo.Valset = histInfo.Valset[1:]
+			},
+			nil, ErrInvalidValidatorSet,
+		},
+		{
+			"tampered next validators hash",
+			func(histInfo *stakingtypes.HistoricalInfo) {
+				histInfo.Header.NextValidatorsHash = testNextValidatorsHash
+			},
+			nil, ErrInvalidValidatorSet,
+		},
+		{
+			"truncated app hash",
+			func(histInfo *stakingtypes.HistoricalInfo) {
+				histInfo.Header.AppHash = histInfo.Header.AppHash[:16]
+			},
+			clienttypes.ErrInvalidConsensus, clienttypes.ErrInvalidConsensus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histInfo := newTestHistoricalInfo(5)
+			histInfo.Valset = append([]stakingtypes.Validator(nil), valSet...)
+			histInfo.Header.NextValidatorsHash = valSetHash
+			tc.malleate(&histInfo)
+
+			host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo}).(*ConsensusHost)
+			ctx := newTestContext(testChainID, 10)
+			height := clienttypes.NewHeight(1, 5)
+
+			fast, err := host.GetSelfConsensusState(ctx, height)
+			if tc.expFast == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expFast)
+			}
+
+			strict, err := host.GetSelfConsensusStateStrict(ctx, height)
+			if tc.expStrict == nil {
+				require.NoError(t, err)
+				require.Equal(t, fast, strict)
+			} else {
+				require.ErrorIs(t, err, tc.expStrict)
+			}
+		})
+	}
+}
+
+func TestCheckRevisionMatch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		chainID string
+		height  clienttypes.Height
+		expPass bool
+	}{
+		{"matching revision", "union-devnet-1", clienttypes.NewHeight(1, 5), true},
+		{"matching zero revision", "union", clienttypes.NewHeight(0, 5), true},
+		{"lower revision", "union-devnet-2", clienttypes.NewHeight(1, 5), false},
+		{"higher revision", "union-devnet-1", clienttypes.NewHeight(2, 5), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := (&ConsensusHost{}).checkRevisionMatch(tc.chainID, tc.height)
+			exportedErr := CheckRevisionMatch(tc.chainID, tc.height)
+			if tc.expPass {
+				require.NoError(t, err)
+				require.NoError(t, exportedErr)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidHeight)
+				require.ErrorIs(t, exportedErr, clienttypes.ErrInvalidHeight)
+			}
+		})
+	}
+}
+
+func TestNeedsRevisionUpgrade(t *testing.T) {
+	testCases := []struct {
+		name        string
+		chainID     string
+		expUpgrade  bool
+		expRevision uint64
+	}{
+		{"matching revision", "union-devnet-1", false, 1},
+		{"bumped revision", "union-devnet-2", true, 2},
+		{"lower revision", "union", false, 0},
+		{"invalid revision", "union-devnet-18446744073709551616", false, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(tc.chainID, 10)
+			clientState := newTestClientState()
+			clientState.ChainId = tc.chainID
+
+			upgrade, revision := NeedsRevisionUpgrade(ctx, clientState)
+			require.Equal(t, tc.expUpgrade, upgrade)
+			require.Equal(t, tc.expRevision, revision)
+
+			if tc.expUpgrade {
+				err := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200}).ValidateSelfClient(ctx, clientState)
+				require.ErrorIs(t, err, clienttypes.ErrInvalidHeight)
+			}
+		})
+	}
+}
+
+func TestValidateSelfClientPeriods(t *testing.T) {
+	testCases := []struct {
+		name            string
+		trustingPeriod  uint64
+		unbondingPeriod uint64
+		expPass         bool
+	}{
+		{"valid periods", 100, 200, true},
+		{"zero unbonding period", 100, 0, false},
+		{"zero trusting period", 0, 200, false},
+		{"trusting period greater than unbonding period", 300, 200, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(mockStakingKeeper{ubdPeriod: time.Duration(tc.unbondingPeriod)})
+			clientState := newTestClientState()
+			clientState.TrustingPeriod = tc.trustingPeriod
+			clientState.UnbondingPeriod = tc.unbondingPeriod
+
+			err := host.ValidateSelfClient(newTestContext(testChainID, 10), clientState)
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+			}
+		})
+	}
+}
+
+// unreachableStakingKeeper fails the test as soon as it is called.
+type unreachableStakingKeeper struct {
+	t *testing.T
+}
+
+func (k unreachableStakingKeeper) GetHistoricalInfo(_ context.Context, _ int64) (stakingtypes.HistoricalInfo, error) {
+	k.t.Fatal("unexpected call to GetHistoricalInfo")
+	return stakingtypes.HistoricalInfo{}, nil
+}
+
+func (k unreachableStakingKeeper) UnbondingTime(_ context.Context) (time.Duration, error) {
+	k.t.Fatal("unexpected call to UnbondingTime")
+	return 0, nil
+}
+
+func TestConsensusHostCancelledContext(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx := newTestContext(testChainID, 10).WithContext(cancelledCtx)
+
+	host := NewConsensusHost(unreachableStakingKeeper{t})
+
+	_, err := host.GetSelfConsensusState(ctx, clienttypes.NewHeight(1, 5))
+	require.ErrorIs(t, err, ErrContextDone)
+
+	err = host.ValidateSelfClient(ctx, newTestClientState())
+	require.ErrorIs(t, err, ErrContextDone)
+}
+
+func TestValidateSelfClientTrustingPeriodFn(t *testing.T) {
+	errParams := errors.New("params failure")
+
+	testCases := []struct {
+		name           string
+		opts           []ConsensusHostOption
+		trustingPeriod uint64
+		expErr         error
+	}{
+		{"default trusting period bound", nil, 150, nil},
+		{"default trusting period above unbonding period", nil, 250, clienttypes.ErrInvalidClient},
+		{
+			"matching overridden trusting period",
+			[]ConsensusHostOption{WithTrustingPeriodFn(func(sdk.Context) (time.Duration, error) { return 150, nil })},
+			150,
+			nil,
+		},
+		{
+			"mismatched overridden trusting period",
+			[]ConsensusHostOption{WithTrustingPeriodFn(func(sdk.Context) (time.Duration, error) { return 150, nil })},
+			100,
+			clienttypes.ErrInvalidClient,
+		},
+		{
+			"overridden trusting period failure",
+			[]ConsensusHostOption{WithTrustingPeriodFn(func(sdk.Context) (time.Duration, error) { return 0, errParams })},
+			150,
+			errParams,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200}, tc.opts...)
+			clientState := newTestClientState()
+			clientState.TrustingPeriod = tc.trustingPeriod
+
+			err := host.ValidateSelfClient(newTestContext(testChainID, 10), clientState)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+type capturedLog struct {
+	level   string
+	msg     string
+	keyVals []any
+}
+
+// captureLogger records every log entry, along with the key/value pairs of its ancestors.
+type captureLogger struct {
+	keyVals []any
+	logs    *[]capturedLog
+}
+
+var _ log.Logger = captureLogger{}
+
+func newCaptureLogger() captureLogger {
+	return captureLogger{logs: &[]capturedLog{}}
+}
+
+func (l captureLogger) log(level, msg string, keyVals []any) {
+	*l.logs = append(*l.logs, capturedLog{level, msg, append(append([]any{}, l.keyVals...), keyVals...)})
+}
+
+func (l captureLogger) Info(msg string, keyVals ...any)  { l.log("info", msg, keyVals) }
+func (l captureLogger) Warn(msg string, keyVals ...any)  { l.log("warn", msg, keyVals) }
+func (l captureLogger) Error(msg string, keyVals ...any) { l.log("error", msg, keyVals) }
+func (l captureLogger) Debug(msg string, keyVals ...any) { l.log("debug", msg, keyVals) }
+func (l captureLogger) Impl() any                        { return l }
+
+func (l captureLogger) With(keyVals ...any) log.Logger {
+	return captureLogger{keyVals: append(append([]any{}, l.keyVals...), keyVals...), logs: l.logs}
+}
+
+func TestValidateSelfClientLogging(t *testing.T) {
+	host := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200})
+
+	logger := newCaptureLogger()
+	ctx := newTestContext(testChainID, 10).WithLogger(logger)
+
+	clientState := newTestClientState()
+	require.NoError(t, host.ValidateSelfClient(ctx, clientState))
+	require.Empty(t, *logger.logs)
+
+	clientState.FrozenHeight = FrozenHeight
+	require.ErrorIs(t, host.ValidateSelfClient(ctx, clientState), clienttypes.ErrClientFrozen)
+	require.Equal(t, []capturedLog{{
+		level:   "debug",
+		msg:     "rejected self client",
+		keyVals: []any{"module", "cometbls-client", "reason", "client is frozen", "frozen_height", FrozenHeight},
+	}}, *logger.logs)
+}
+
+func TestValidateSelfClientLatestHeight(t *testing.T) {
+	testCases := []struct {
+		name         string
+		latestHeight clienttypes.Height
+		expPass      bool
+	}{
+		{"non-zero revision height", clienttypes.NewHeight(1, 1), true},
+		{"zero revision height", clienttypes.NewHeight(1, 0), false},
+		{"revision height equal to chain height", clienttypes.NewHeight(1, 10), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200})
+			clientState := newTestClientState()
+			clientState.LatestHeight = tc.latestHeight
+
+			err := host.ValidateSelfClient(newTestContext(testChainID, 10), clientState)
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+			}
+		})
+	}
+}
+
+func TestValidateSelfClientBlockHeight(t *testing.T) {
+	testCases := []struct {
+		name        string
+		blockHeight int64
+		expPass     bool
+	}{
+		{"positive block height", 10, true},
+		{"zero block height", 0, false},
+		{"negative block height", -1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200})
+
+			err := host.ValidateSelfClient(newTestContext(testChainID, tc.blockHeight), newTestClientState())
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidHeight)
+				require.ErrorContains(t, err, "chain height must be positive")
+			}
+		})
+	}
+}
+
+// historicalStakingKeeper serves the historical info stored for each height.
+type historicalStakingKeeper map[int64]stakingtypes.HistoricalInfo
+
+func (k historicalStakingKeeper) GetHistoricalInfo(_ context.Context, height int64) (stakingtypes.HistoricalInfo, error) {
+	histInfo, found := k[height]
+	if !found {
+		return stakingtypes.HistoricalInfo{}, stakingtypes.ErrNoHistoricalInfo
+	}
+	return histInfo, nil
+}
+
+func (historicalStakingKeeper) UnbondingTime(_ context.Context) (time.Duration, error) {
+	return 0, nil
+}
+
+func TestBatchGetSelfConsensusState(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	keeper := historicalStakingKeeper{}
+	for _, height := range []int64{3, 5, 7} {
+		histInfo := newTestHistoricalInfo(height)
+		histInfo.Header.Time = histInfo.Header.Time.Add(time.Duration(height) * time.Second)
+		keeper[height] = histInfo
+	}
+	host := NewConsensusHost(keeper).(*ConsensusHost)
+
+	// consensus states are returned in input order
+	heights := []exported.Height{clienttypes.NewHeight(1, 7), clienttypes.NewHeight(1, 3), clienttypes.NewHeight(1, 5)}
+	consensusStates, err := host.BatchGetSelfConsensusState(ctx, heights)
+	require.NoError(t, err)
+	require.Len(t, consensusStates, len(heights))
+	for i, height := range heights {
+		expected, err := host.GetSelfConsensusState(ctx, height)
+		require.NoError(t, err)
+		require.Equal(t, expected, consensusStates[i])
+		require.Equal(t, keeper[int64(height.GetRevisionHeight())].Header.Time.UnixNano(), int64(consensusStates[i].GetTimestamp()))
+	}
+
+	_, err = host.BatchGetSelfConsensusState(ctx, []exported.Height{
+		clienttypes.NewHeight(1, 5), clienttypes.NewHeight(1, 6), clienttypes.NewHeight(1, 7),
+	})
+	require.ErrorIs(t, err, ErrHistoricalInfoUnavailable)
+	require.ErrorContains(t, err, "batch height 1-6 at index 1")
+
+	consensusStates, err = host.BatchGetSelfConsensusState(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, consensusStates)
+}
+
+func TestValidateChainID(t *testing.T) {
+	testCases := []struct {
+		name    string
+		chainID string
+		expPass bool
+	}{
+		{"valid chain id", testChainID, true},
+		{"chain id at max length", strings.Repeat("a", cmttypes.MaxChainIDLen), true},
+		{"empty chain id", "", false},
+		{"blank chain id", "  ", false},
+		{"too long chain id", strings.Repeat("a", cmttypes.MaxChainIDLen+1), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateChainID(tc.chainID)
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+			}
+
+			// malformed chain ids are rejected before being compared to the executing chain id
+			clientState := newTestClientState()
+			clientState.ChainId = tc.chainID
+			err = NewConsensusHost(mockStakingKeeper{ubdPeriod: 200}).ValidateSelfClient(newTestContext(tc.chainID, 10), clientState)
+			if tc.expPass {
+				require.NotErrorIs(t, err, clienttypes.ErrInvalidClient)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+			}
+		})
+	}
+}
+
+// historicalEntriesStakingKeeper additionally exposes the historical entries staking parameter.
+type historicalEntriesStakingKeeper struct {
+	mockStakingKeeper
+	entries    uint32
+	entriesErr error
+}
+
+func (k historicalEntriesStakingKeeper) HistoricalEntries(_ context.Context) (uint32, error) {
+	return k.entries, k.entriesErr
+}
+
+func TestGetSelfConsensusStateHistoricalLookback(t *testing.T) {
+	errParams := errors.New("params failure")
+
+	testCases := []struct {
+		name       string
+		height     uint64
+		entries    uint32
+		entriesErr error
+		expErr     error
+	}{
+		{"height within the window", 5, 10, nil, nil},
+		{"oldest height of the window", 5, 6, nil, nil},
+		{"current height", 10, 1, nil, nil},
+		{"height older than the window", 5, 5, nil, ErrHistoricalInfoUnavailable},
+		{"height newer than the window", 11, 10, nil, ErrHistoricalInfoUnavailable},
+		{"no historical entries retained", 10, 0, nil, ErrHistoricalInfoUnavailable},
+		{"historical entries failure", 5, 10, errParams, errParams},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewConsensusHost(historicalEntriesStakingKeeper{
+				mockStakingKeeper: mockStakingKeeper{histInfo: newTestHistoricalInfo(int64(tc.height))},
+				entries:           tc.entries,
+				entriesErr:        tc.entriesErr,
+			})
+
+			_, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, tc.height))
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestGetMaxHistoricalLookback(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+
+	lookback, err := NewConsensusHost(historicalEntriesStakingKeeper{entries: 10000}).(*ConsensusHost).GetMaxHistoricalLookback(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(10000), lookback)
+
+	_, err = NewConsensusHost(mockStakingKeeper{}).(*ConsensusHost).GetMaxHistoricalLookback(ctx)
+	require.Error(t, err)
+}
+
+func FuzzChainIDRevision(f *testing.F) {
+	for _, chainID := range []string{"foo-0", "foo-bar-10", "", "union-devnet-1", "foo-", "-1", "foo-01", "foo-18446744073709551615", "foo-18446744073709551616"} {
+		f.Add(chainID)
+	}
+
+	// share the host across inputs to exercise the revision cache
+	host := &ConsensusHost{}
+	f.Fuzz(func(t *testing.T, chainID string) {
+		revision, err := host.parseChainID(chainID)
+		if err != nil {
+			// clienttypes.ParseChainID panics on revisions overflowing a uint64
+			require.ErrorIs(t, err, ErrInvalidChainID)
+			require.Panics(t, func() { clienttypes.ParseChainID(chainID) })
+			require.ErrorIs(t, host.checkRevisionMatch(chainID, clienttypes.NewHeight(0, 1)), ErrInvalidChainID)
+			return
+		}
+
+		require.Equal(t, clienttypes.ParseChainID(chainID), revision)
+		require.NoError(t, host.checkRevisionMatch(chainID, clienttypes.NewHeight(revision, 1)))
+		require.ErrorIs(t, host.checkRevisionMatch(chainID, clienttypes.NewHeight(revision+1, 1)), clienttypes.ErrInvalidHeight)
+	})
+}
+
+func TestWithStakingKeeper(t *testing.T) {
+	histInfo := newTestHistoricalInfo(5)
+	histInfo.Header.Time = time.Unix(1710783300, 0)
+
+	host := NewConsensusHost(unreachableStakingKeeper{t}, WithStakingKeeper(mockStakingKeeper{histInfo: histInfo}))
+
+	consensusState, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, 5))
+	require.NoError(t, err)
+	require.Equal(t, uint64(histInfo.Header.Time.UnixNano()), consensusState.GetTimestamp())
+	require.Equal(t, histInfo.Header.AppHash, consensusState.(*ConsensusState).Root.Hash)
+}
+
+func TestGetSelfConsensusStateAppHash(t *testing.T) {
+	testCases := []struct {
+		name    string
+		appHash []byte
+		expPass bool
+	}{
+		{"valid app hash", testAppHash, true},
+		{"empty app hash", nil, false},
+		{"short app hash", testAppHash[:31], false},
+		{"long app hash", append(append([]byte{}, testAppHash...), 0xaa), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histInfo := newTestHistoricalInfo(5)
+			histInfo.Header.AppHash = tc.appHash
+
+			host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo})
+
+			_, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, 5))
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+			}
+		})
+	}
+}
+
+func TestGetSelfConsensusStateEmptyNextValidatorsHash(t *testing.T) {
+	validators, nextValsHash := newTestValidatorSet(t, 3)
+
+	testCases := []struct {
+		name         string
+		valset       []stakingtypes.Validator
+		nextValsHash []byte
+		expErr       error
+	}{
+		{"valid next validators hash", nil, testNextValidatorsHash, nil},
+		{"valid next validators hash of the validator set", validators, nextValsHash, nil},
+		{"empty next validators hash", nil, nil, clienttypes.ErrInvalidConsensus},
+		{"empty next validators hash with a validator set", validators, []byte{}, clienttypes.ErrInvalidConsensus},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histInfo := newTestHistoricalInfo(5)
+			histInfo.Valset = tc.valset
+			histInfo.Header.NextValidatorsHash = tc.nextValsHash
+
+			host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo})
+
+			consensusState, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, 5))
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.nextValsHash, []byte(consensusState.(*ConsensusState).NextValidatorsHash))
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.ErrorContains(t, err, "next validators hash cannot be empty")
+			}
+		})
+	}
+}
+
+func TestGetSelfConsensusStateValidateBasic(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(histInfo *stakingtypes.HistoricalInfo)
+		expPass  bool
+	}{
+		{"valid historical info", func(_ *stakingtypes.HistoricalInfo) {}, true},
+		{"zero header time", func(histInfo *stakingtypes.HistoricalInfo) { histInfo.Header.Time = time.Unix(0, 0) }, false},
+		{"short next validators hash", func(histInfo *stakingtypes.HistoricalInfo) {
+			histInfo.Header.NextValidatorsHash = testNextValidatorsHash[:31]
+		}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histInfo := newTestHistoricalInfo(5)
+			tc.malleate(&histInfo)
+
+			host := NewConsensusHost(mockStakingKeeper{histInfo: histInfo})
+
+			consensusState, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), clienttypes.NewHeight(1, 5))
+			if tc.expPass {
+				require.NoError(t, err)
+				require.NoError(t, consensusState.ValidateBasic())
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+			}
+		})
+	}
+}
+
+func TestGetSelfConsensusStateHeightOverflow(t *testing.T) {
+	host := NewConsensusHost(unreachableStakingKeeper{t})
+	ctx := newTestContext(testChainID, 10)
+
+	for _, revisionHeight := range []uint64{math.MaxInt64 + 1, math.MaxUint64} {
+		_, err := host.GetSelfConsensusState(ctx, clienttypes.NewHeight(1, revisionHeight))
+		require.ErrorIs(t, err, clienttypes.ErrInvalidHeight)
+	}
+}
+
+func TestConsensusHostConcurrentRevisionCache(t *testing.T) {
+	host := NewConsensusHost(historicalStakingKeeper{
+		5: newTestHistoricalInfo(5),
+	})
+
+	chainIDs := []string{testChainID, "union-devnet-2", "union"}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(chainID string) {
+			defer wg.Done()
+
+			revision := clienttypes.ParseChainID(chainID)
+			_, err := host.GetSelfConsensusState(newTestContext(chainID, 10), clienttypes.NewHeight(revision, 5))
+			errs <- err
+		}(chainIDs[i%len(chainIDs)])
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestCheckNotFrozen(t *testing.T) {
+	clientState := newTestClientState()
+	require.NoError(t, CheckNotFrozen(clientState))
+
+	clientState.FrozenHeight = FrozenHeight
+	require.ErrorIs(t, CheckNotFrozen(clientState), clienttypes.ErrClientFrozen)
+}
+
+func TestCheckChainIDMatch(t *testing.T) {
+	require.NoError(t, CheckChainIDMatch(testChainID, testChainID))
+
+	err := CheckChainIDMatch(testChainID, "union-devnet-2")
+	require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+	require.ErrorContains(t, err, "invalid chain-id. expected: "+testChainID+", got: union-devnet-2")
+}
+
+func TestCheckLatestHeightBound(t *testing.T) {
+	selfHeight := clienttypes.NewHeight(1, 10)
+
+	testCases := []struct {
+		name         string
+		latestHeight clienttypes.Height
+		expErr       string
+	}{
+		{"lower than chain height", clienttypes.NewHeight(1, 9), ""},
+		{"zero revision height", clienttypes.NewHeight(1, 0), "client latest height revision height cannot be zero"},
+		{"equal to chain height", clienttypes.NewHeight(1, 10), "greater than or equal to chain height"},
+		{"greater than chain height", clienttypes.NewHeight(1, 11), "greater than or equal to chain height"},
+		{"greater revision", clienttypes.NewHeight(2, 1), "greater than or equal to chain height"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckLatestHeightBound(tc.latestHeight, selfHeight)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestCheckPeriods(t *testing.T) {
+	testCases := []struct {
+		name      string
+		unbonding uint64
+		trusting  uint64
+		expErr    string
+	}{
+		{"unbonding greater than trusting", 200, 100, ""},
+		{"unbonding one more than trusting", 101, 100, ""},
+		{"unbonding equal to trusting", 100, 100, "unbonding period (100) <= trusting period (100)"},
+		{"zero unbonding period", 0, 100, "unbonding period cannot be zero"},
+		{"zero trusting period", 200, 0, "trusting period cannot be zero"},
+		{"unbonding lower than trusting", 100, 200, "unbonding period (100) <= trusting period (200)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckPeriods(tc.unbonding, tc.trusting)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidClient)
+				require.ErrorContains(t, err, tc.expErr)
+			}
+
+			// the client state validation agrees on the periods
+			clientState := newTestClientState()
+			clientState.UnbondingPeriod, clientState.TrustingPeriod = tc.unbonding, tc.trusting
+			require.Equal(t, tc.expErr == "", clientState.ValidateBasic() == nil)
+		})
+	}
+}
+
+// countingStakingKeeper counts the calls to UnbondingTime, failing them while ubdPeriodErr is set.
+type countingStakingKeeper struct {
+	mockStakingKeeper
+	ubdPeriodCalls int
+}
+
+func (k *countingStakingKeeper) UnbondingTime(ctx context.Context) (time.Duration, error) {
+	k.ubdPeriodCalls++
+	return k.mockStakingKeeper.UnbondingTime(ctx)
+}
+
+func TestValidateSelfClientUnbondingTimeCache(t *testing.T) {
+	keeper := &countingStakingKeeper{mockStakingKeeper: mockStakingKeeper{ubdPeriod: 200}}
+	host := NewConsensusHost(keeper)
+	clientState := newTestClientState()
+
+	// the keeper is read once per block height
+	for _, height := range []int64{10, 10, 10, 11, 11, 12, 10} {
+		require.NoError(t, host.ValidateSelfClient(newTestContext(testChainID, height), clientState))
+	}
+	require.Equal(t, 4, keeper.ubdPeriodCalls)
+
+	// keeper errors are not cached
+	keeper.ubdPeriodErr = errors.New("keeper failure")
+	require.ErrorIs(t, host.ValidateSelfClient(newTestContext(testChainID, 13), clientState), keeper.ubdPeriodErr)
+	require.Equal(t, 5, keeper.ubdPeriodCalls)
+
+	keeper.ubdPeriodErr = nil
+	require.NoError(t, host.ValidateSelfClient(newTestContext(testChainID, 13), clientState))
+	require.NoError(t, host.ValidateSelfClient(newTestContext(testChainID, 13), clientState))
+	require.Equal(t, 6, keeper.ubdPeriodCalls)
+
+	// an unbonding time updated at a new block height is picked up
+	keeper.ubdPeriod = 300
+	require.ErrorIs(t, host.ValidateSelfClient(newTestContext(testChainID, 14), clientState), clienttypes.ErrInvalidClient)
+	require.Equal(t, 7, keeper.ubdPeriodCalls)
+}
+
+// prunedStakingKeeper only retains historical info at the given heights.
+type prunedStakingKeeper struct {
+	mockStakingKeeper
+	retained map[int64]bool
+	calls    int
+}
+
+func (k *prunedStakingKeeper) GetHistoricalInfo(_ context.Context, height int64) (stakingtypes.HistoricalInfo, error) {
+	k.calls++
+	if !k.retained[height] {
+		return stakingtypes.HistoricalInfo{}, stakingtypes.ErrNoHistoricalInfo
+	}
+	return newTestHistoricalInfo(height), nil
+}
+
+func TestGetSelfConsensusStateOrEarlier(t *testing.T) {
+	errKeeper := errors.New("keeper failure")
+
+	testCases := []struct {
+		name      string
+		retained  []int64
+		limit     uint64
+		height    uint64
+		histErr   error
+		expHeight uint64
+		expCalls  int
+		expErr    error
+	}{
+		{"exact hit", []int64{5, 8}, 3, 8, nil, 8, 1, nil},
+		{"fallback to the nearest earlier height", []int64{5, 6}, 3, 8, nil, 6, 3, nil},
+		{"fallback at the limit", []int64{5}, 3, 8, nil, 5, 4, nil},
+		{"limit exceeded", []int64{4}, 3, 8, nil, 0, 4, ErrHistoricalInfoUnavailable},
+		{"fallback disabled", []int64{7}, 0, 8, nil, 0, 1, ErrHistoricalInfoUnavailable},
+		{"no fallback below the first height", nil, 10, 2, nil, 0, 2, ErrHistoricalInfoUnavailable},
+		{"keeper failure is not walked past", []int64{5}, 3, 8, errKeeper, 0, 0, errKeeper},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keeper := &prunedStakingKeeper{retained: make(map[int64]bool)}
+			for _, h := range tc.retained {
+				keeper.retained[h] = true
+			}
+
+			var sk StakingKeeper = keeper
+			if tc.histErr != nil {
+				sk = mockStakingKeeper{histInfoErr: tc.histErr}
+			}
+			host := NewConsensusHost(sk, WithSelfConsensusStateFallbackLimit(tc.limit)).(*ConsensusHost)
+
+			consensusState, height, err := host.GetSelfConsensusStateOrEarlier(newTestContext(testChainID, 10), clienttypes.NewHeight(1, tc.height))
+			require.Equal(t, tc.expCalls, keeper.calls)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, clienttypes.NewHeight(1, tc.expHeight), height)
+
+				expConsensusState, err := host.GetSelfConsensusState(newTestContext(testChainID, 10), height)
+				require.NoError(t, err)
+				require.Equal(t, expConsensusState, consensusState)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, consensusState)
+				require.Nil(t, height)
+			}
+		})
+	}
+
+	// the default limit applies when none is configured
+	keeper := &prunedStakingKeeper{retained: map[int64]bool{1: true}}
+	host := NewConsensusHost(keeper).(*ConsensusHost)
+	_, height, err := host.GetSelfConsensusStateOrEarlier(newTestContext(testChainID, 1000), clienttypes.NewHeight(1, DefaultSelfConsensusStateFallbackLimit+1))
+	require.NoError(t, err)
+	require.Equal(t, clienttypes.NewHeight(1, 1), height)
+
+	_, _, err = host.GetSelfConsensusStateOrEarlier(newTestContext(testChainID, 1000), clienttypes.NewHeight(1, DefaultSelfConsensusStateFallbackLimit+2))
+	require.ErrorIs(t, err, ErrHistoricalInfoUnavailable)
+}
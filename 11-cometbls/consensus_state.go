@@ -1,10 +1,14 @@
 package cometbls
 
 import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
 	errorsmod "cosmossdk.io/errors"
 
-	tmbytes "github.com/cometbft/cometbft/libs/bytes"
-	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/cometbft/cometbft/crypto/tmhash"
 
 	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
 	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
@@ -16,15 +20,122 @@ var _ exported.ConsensusState = (*ConsensusState)(nil)
 // SentinelRoot is used as a stand-in root value for the consensus state set at the upgrade height
 const SentinelRoot = "sentinel_root"
 
-// NewConsensusState creates a new ConsensusState instance.
+// NewConsensusState creates a new ConsensusState instance. It returns an error if
+// the consensus state does not pass ValidateBasic.
 func NewConsensusState(
-	timestamp uint64, root commitmenttypes.MerkleRoot, nextValsHash tmbytes.HexBytes,
-) *ConsensusState {
-	return &ConsensusState{
+	timestamp uint64, root commitmenttypes.MerkleRoot, nextValsHash []byte,
+) (*ConsensusState, error) {
+	consensusState := &ConsensusState{
 		Timestamp:          timestamp,
 		Root:               root,
 		NextValidatorsHash: nextValsHash,
 	}
+	if err := consensusState.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	return consensusState, nil
+}
+
+const (
+	// ConsensusStateVersionLegacy is the legacy consensus state layout, storing the timestamp in seconds.
+	ConsensusStateVersionLegacy = 1
+	// ConsensusStateVersion is the current consensus state layout, storing the timestamp in nanoseconds.
+	ConsensusStateVersion = 2
+)
+
+// MigrateConsensusState returns a copy of the consensus state migrated from the given layout version to the
+// current one. The timestamp of a legacy consensus state is rescaled from seconds to nanoseconds, a consensus
+// state already in the current layout is returned unchanged. The rescaling is lossless and can be reversed with
+// RevertConsensusStateMigration.
+// An ErrInvalidConsensus is returned for an unknown version or a timestamp not representable in nanoseconds.
+func MigrateConsensusState(cs *ConsensusState, fromVersion int) (*ConsensusState, error) {
+	if cs == nil {
+		return nil, errorsmod.Wrap(clienttypes.ErrInvalidConsensus, "consensus state cannot be nil")
+	}
+
+	migrated := cs.Copy()
+	switch fromVersion {
+	case ConsensusStateVersion:
+		return migrated, nil
+	case ConsensusStateVersionLegacy:
+		// timestamps are converted to a time.Time as int64 nanoseconds
+		if cs.Timestamp > math.MaxInt64/uint64(time.Second) {
+			return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "legacy timestamp %d overflows once converted to nanoseconds", cs.Timestamp)
+		}
+		migrated.Timestamp = cs.Timestamp * uint64(time.Second)
+		return migrated, nil
+	default:
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "unknown consensus state version %d", fromVersion)
+	}
+}
+
+// RevertConsensusStateMigration returns a copy of the consensus state migrated from the current layout back to the
+// given layout version, reversing MigrateConsensusState. A consensus state reverted to the legacy layout has its
+// timestamp rescaled from nanoseconds to seconds.
+// An ErrInvalidConsensus is returned for an unknown version or a timestamp that is not a whole number of seconds,
+// as it could not be migrated back to the same consensus state.
+func RevertConsensusStateMigration(cs *ConsensusState, toVersion int) (*ConsensusState, error) {
+	if cs == nil {
+		return nil, errorsmod.Wrap(clienttypes.ErrInvalidConsensus, "consensus state cannot be nil")
+	}
+
+	reverted := cs.Copy()
+	switch toVersion {
+	case ConsensusStateVersion:
+		return reverted, nil
+	case ConsensusStateVersionLegacy:
+		if cs.Timestamp%uint64(time.Second) != 0 {
+			return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "timestamp %d cannot be represented in seconds without losing precision", cs.Timestamp)
+		}
+		reverted.Timestamp = cs.Timestamp / uint64(time.Second)
+		return reverted, nil
+	default:
+		return nil, errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "unknown consensus state version %d", toVersion)
+	}
+}
+
+// Equal returns true if both consensus states are nil or if all of their fields are equal.
+func (cs *ConsensusState) Equal(other *ConsensusState) bool {
+	if cs == nil || other == nil {
+		return cs == other
+	}
+
+	return cs.Timestamp == other.Timestamp &&
+		bytes.Equal(cs.Root.Hash, other.Root.Hash) &&
+		bytes.Equal(cs.NextValidatorsHash, other.NextValidatorsHash)
+}
+
+// Copy returns a deep copy of the consensus state, which can be mutated without affecting the original.
+// A nil consensus state is copied as nil.
+func (cs *ConsensusState) Copy() *ConsensusState {
+	if cs == nil {
+		return nil
+	}
+
+	return &ConsensusState{
+		Timestamp:          cs.Timestamp,
+		Root:               commitmenttypes.NewMerkleRoot(bytes.Clone(cs.Root.Hash)),
+		NextValidatorsHash: bytes.Clone(cs.NextValidatorsHash),
+	}
+}
+
+// Summary returns a human readable rendering of the consensus state with truncated hashes, e.g. for debugging and
+// CLI output. The generated String method renders the protobuf text format instead.
+func (cs ConsensusState) Summary() string {
+	return fmt.Sprintf(
+		"ConsensusState{timestamp: %s, root: %s, next_validators_hash: %s}",
+		cs.GetTime().Format(time.RFC3339Nano), truncateHash(cs.Root.Hash), truncateHash(cs.NextValidatorsHash),
+	)
+}
+
+// truncateHash renders the first bytes of the hash in hexadecimal.
+func truncateHash(hash []byte) string {
+	const size = 4
+	if len(hash) <= size {
+		return fmt.Sprintf("%X", hash)
+	}
+	return fmt.Sprintf("%X...", hash[:size])
 }
 
 // ClientType returns Tendermint
@@ -42,15 +153,30 @@ func (cs ConsensusState) GetTimestamp() uint64 {
 	return uint64(cs.Timestamp)
 }
 
-// ValidateBasic defines a basic validation for the tendermint consensus state.
+// GetTimestampNanos returns the Unix timestamp in nanoseconds of the header that created consensus state
+func (cs ConsensusState) GetTimestampNanos() uint64 {
+	return cs.Timestamp
+}
+
+// GetTime returns the block time of the header that created consensus state
+func (cs ConsensusState) GetTime() time.Time {
+	return time.Unix(0, int64(cs.Timestamp)).UTC()
+}
+
+// ValidateBasic defines a basic validation for the tendermint consensus state. It returns an error if the
+// timestamp is zero, the root is empty or the next validators hash is not a 32 bytes hash.
 // NOTE: ProcessedTimestamp may be zero if this is an initial consensus state passed in by relayer
 // as opposed to a consensus state constructed by the chain.
 func (cs ConsensusState) ValidateBasic() error {
+	if cs.Timestamp == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidConsensus, "timestamp cannot be zero")
+	}
 	if cs.Root.Empty() {
 		return errorsmod.Wrap(clienttypes.ErrInvalidConsensus, "root cannot be empty")
 	}
-	if err := tmtypes.ValidateHash(cs.NextValidatorsHash); err != nil {
-		return errorsmod.Wrap(err, "next validators hash is invalid")
+	if len(cs.NextValidatorsHash) != tmhash.Size {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "next validators hash must be %d bytes, got: %d",
+			tmhash.Size, len(cs.NextValidatorsHash))
 	}
 	return nil
 }
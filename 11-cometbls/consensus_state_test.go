@@ -0,0 +1,265 @@
+package cometbls
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAppHash            = bytes.Repeat([]byte{0xaa}, 32)
+	testNextValidatorsHash = bytes.Repeat([]byte{0xbb}, 32)
+)
+
+func TestNewConsensusState(t *testing.T) {
+	testCases := []struct {
+		name         string
+		timestamp    uint64
+		root         commitmenttypes.MerkleRoot
+		nextValsHash []byte
+		expPass      bool
+	}{
+		{"valid consensus state", 1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash, true},
+		{"zero timestamp", 0, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash, false},
+		{"empty root", 1, commitmenttypes.MerkleRoot{}, testNextValidatorsHash, false},
+		{"empty next validators hash", 1, commitmenttypes.NewMerkleRoot(testAppHash), nil, false},
+		{"short next validators hash", 1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash[:31], false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			consensusState, err := NewConsensusState(tc.timestamp, tc.root, tc.nextValsHash)
+			if tc.expPass {
+				require.NoError(t, err)
+				require.Equal(t, tc.timestamp, consensusState.Timestamp)
+				require.Equal(t, tc.root, consensusState.Root)
+				require.Equal(t, tc.nextValsHash, consensusState.NextValidatorsHash)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+				require.Nil(t, consensusState)
+			}
+		})
+	}
+}
+
+func TestConsensusStateValidateBasic(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(cs *ConsensusState)
+		expPass  bool
+	}{
+		{"valid consensus state", func(_ *ConsensusState) {}, true},
+		{"sentinel root", func(cs *ConsensusState) { cs.Root = commitmenttypes.NewMerkleRoot([]byte(SentinelRoot)) }, true},
+		{"zero timestamp", func(cs *ConsensusState) { cs.Timestamp = 0 }, false},
+		{"empty root", func(cs *ConsensusState) { cs.Root = commitmenttypes.MerkleRoot{} }, false},
+		{"empty next validators hash", func(cs *ConsensusState) { cs.NextValidatorsHash = nil }, false},
+		{"short next validators hash", func(cs *ConsensusState) { cs.NextValidatorsHash = testNextValidatorsHash[:31] }, false},
+		{"long next validators hash", func(cs *ConsensusState) { cs.NextValidatorsHash = append(bytes.Clone(testNextValidatorsHash), 0xbb) }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			consensusState := ConsensusState{
+				Timestamp:          1,
+				Root:               commitmenttypes.NewMerkleRoot(testAppHash),
+				NextValidatorsHash: testNextValidatorsHash,
+			}
+			tc.malleate(&consensusState)
+
+			err := consensusState.ValidateBasic()
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+			}
+		})
+	}
+}
+
+func TestConsensusStateEqual(t *testing.T) {
+	newConsensusState := func() *ConsensusState {
+		consensusState, err := NewConsensusState(1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+		require.NoError(t, err)
+		return consensusState
+	}
+
+	testCases := []struct {
+		name     string
+		malleate func(consensusState *ConsensusState)
+		expEqual bool
+	}{
+		{"equal", func(_ *ConsensusState) {}, true},
+		{"different timestamp", func(cs *ConsensusState) { cs.Timestamp++ }, false},
+		{"different root", func(cs *ConsensusState) { cs.Root = commitmenttypes.NewMerkleRoot(testNextValidatorsHash) }, false},
+		{"different next validators hash", func(cs *ConsensusState) { cs.NextValidatorsHash = testAppHash }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			consensusState := newConsensusState()
+			tc.malleate(consensusState)
+			require.Equal(t, tc.expEqual, newConsensusState().Equal(consensusState))
+			require.Equal(t, tc.expEqual, consensusState.Equal(newConsensusState()))
+		})
+	}
+
+	var nilConsensusState *ConsensusState
+	require.True(t, nilConsensusState.Equal(nil))
+	require.False(t, nilConsensusState.Equal(newConsensusState()))
+	require.False(t, newConsensusState().Equal(nil))
+}
+
+func TestConsensusStateTime(t *testing.T) {
+	timestamp := time.Unix(1710783278, 499600406)
+
+	consensusState, err := NewConsensusState(uint64(timestamp.UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(timestamp.UnixNano()), consensusState.GetTimestampNanos())
+	require.Equal(t, consensusState.GetTimestamp(), consensusState.GetTimestampNanos())
+	require.True(t, timestamp.Equal(consensusState.GetTime()))
+	require.Equal(t, timestamp.UTC(), consensusState.GetTime())
+}
+
+func TestConsensusStateCopy(t *testing.T) {
+	newConsensusState := func() *ConsensusState {
+		consensusState, err := NewConsensusState(1, commitmenttypes.NewMerkleRoot(bytes.Clone(testAppHash)), bytes.Clone(testNextValidatorsHash))
+		require.NoError(t, err)
+		return consensusState
+	}
+	consensusState := newConsensusState()
+
+	copied := consensusState.Copy()
+	require.True(t, consensusState.Equal(copied))
+
+	copied.Timestamp++
+	copied.Root.Hash[0] ^= 0xff
+	copied.NextValidatorsHash[0] ^= 0xff
+	require.True(t, newConsensusState().Equal(consensusState))
+
+	var nilConsensusState *ConsensusState
+	require.Nil(t, nilConsensusState.Copy())
+}
+
+func TestConsensusStateSummary(t *testing.T) {
+	consensusState, err := NewConsensusState(uint64(time.Unix(1710783278, 499600406).UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		"ConsensusState{timestamp: 2024-03-18T17:34:38.499600406Z, root: AAAAAAAA..., next_validators_hash: BBBBBBBB...}",
+		consensusState.Summary(),
+	)
+}
+
+func TestConsensusStateJSON(t *testing.T) {
+	cdc := newTestCodec().(codec.Codec)
+
+	consensusState, err := NewConsensusState(1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+
+	bz, err := cdc.MarshalJSON(consensusState)
+	require.NoError(t, err)
+	require.Contains(t, string(bz), `"next_validators_hash":`)
+
+	var unmarshalled ConsensusState
+	require.NoError(t, cdc.UnmarshalJSON(bz, &unmarshalled))
+	require.True(t, consensusState.Equal(&unmarshalled))
+}
+
+func TestMigrateConsensusState(t *testing.T) {
+	legacyTime := time.Unix(1710783278, 0).UTC()
+
+	testCases := []struct {
+		name         string
+		timestamp    uint64
+		fromVersion  int
+		expTimestamp uint64
+		expErr       error
+	}{
+		{"legacy seconds layout", uint64(legacyTime.Unix()), ConsensusStateVersionLegacy, uint64(legacyTime.UnixNano()), nil},
+		{"largest legacy timestamp", math.MaxInt64 / uint64(time.Second), ConsensusStateVersionLegacy, math.MaxInt64 / uint64(time.Second) * uint64(time.Second), nil},
+		{"already current", uint64(legacyTime.UnixNano()), ConsensusStateVersion, uint64(legacyTime.UnixNano()), nil},
+		{"overflowing legacy timestamp", math.MaxInt64/uint64(time.Second) + 1, ConsensusStateVersionLegacy, 0, clienttypes.ErrInvalidConsensus},
+		{"unknown version", uint64(legacyTime.Unix()), 42, 0, clienttypes.ErrInvalidConsensus},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			consensusState, err := NewConsensusState(tc.timestamp, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			original := consensusState.Copy()
+
+			migrated, err := MigrateConsensusState(consensusState, tc.fromVersion)
+			require.True(t, original.Equal(consensusState), "input must not be modified")
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, migrated)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expTimestamp, migrated.Timestamp)
+			require.Equal(t, consensusState.Root, migrated.Root)
+			require.Equal(t, consensusState.NextValidatorsHash, migrated.NextValidatorsHash)
+			require.NoError(t, migrated.ValidateBasic())
+		})
+	}
+
+	// the time of a migrated legacy consensus state is the one it was recorded with
+	migrated, err := MigrateConsensusState(&ConsensusState{Timestamp: uint64(legacyTime.Unix())}, ConsensusStateVersionLegacy)
+	require.NoError(t, err)
+	require.Equal(t, legacyTime, migrated.GetTime())
+
+	_, err = MigrateConsensusState(nil, ConsensusStateVersionLegacy)
+	require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+}
+
+func TestRevertConsensusStateMigration(t *testing.T) {
+	legacyTime := time.Unix(1710783278, 0).UTC()
+
+	testCases := []struct {
+		name         string
+		timestamp    uint64
+		toVersion    int
+		expTimestamp uint64
+		expErr       error
+	}{
+		{"legacy seconds layout", uint64(legacyTime.UnixNano()), ConsensusStateVersionLegacy, uint64(legacyTime.Unix()), nil},
+		{"already current", uint64(legacyTime.UnixNano()), ConsensusStateVersion, uint64(legacyTime.UnixNano()), nil},
+		{"sub-second timestamp", uint64(legacyTime.UnixNano()) + 1, ConsensusStateVersionLegacy, 0, clienttypes.ErrInvalidConsensus},
+		{"unknown version", uint64(legacyTime.UnixNano()), 42, 0, clienttypes.ErrInvalidConsensus},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			consensusState, err := NewConsensusState(tc.timestamp, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			original := consensusState.Copy()
+
+			reverted, err := RevertConsensusStateMigration(consensusState, tc.toVersion)
+			require.True(t, original.Equal(consensusState), "input must not be modified")
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Nil(t, reverted)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expTimestamp, reverted.Timestamp)
+
+			// migrating the reverted consensus state gives back the original one
+			migrated, err := MigrateConsensusState(reverted, tc.toVersion)
+			require.NoError(t, err)
+			require.True(t, original.Equal(migrated))
+		})
+	}
+
+	_, err := RevertConsensusStateMigration(nil, ConsensusStateVersionLegacy)
+	require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+}
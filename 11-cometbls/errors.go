@@ -6,18 +6,31 @@ import (
 
 // IBC tendermint client sentinel errors
 var (
-	ErrInvalidChainID          = errorsmod.Register(ModuleName, 2, "invalid chain-id")
-	ErrInvalidTrustingPeriod   = errorsmod.Register(ModuleName, 3, "invalid trusting period")
-	ErrInvalidUnbondingPeriod  = errorsmod.Register(ModuleName, 4, "invalid unbonding period")
-	ErrInvalidHeaderHeight     = errorsmod.Register(ModuleName, 5, "invalid header height")
-	ErrInvalidHeader           = errorsmod.Register(ModuleName, 6, "invalid header")
-	ErrInvalidMaxClockDrift    = errorsmod.Register(ModuleName, 7, "invalid max clock drift")
-	ErrProcessedTimeNotFound   = errorsmod.Register(ModuleName, 8, "processed time not found")
-	ErrProcessedHeightNotFound = errorsmod.Register(ModuleName, 9, "processed height not found")
-	ErrDelayPeriodNotPassed    = errorsmod.Register(ModuleName, 10, "packet-specified delay period has not been reached")
-	ErrTrustingPeriodExpired   = errorsmod.Register(ModuleName, 11, "time since latest trusted state has passed the trusting period")
-	ErrUnbondingPeriodExpired  = errorsmod.Register(ModuleName, 12, "time since latest trusted state has passed the unbonding period")
-	ErrInvalidProofSpecs       = errorsmod.Register(ModuleName, 13, "invalid proof specs")
-	ErrInvalidValidatorSet     = errorsmod.Register(ModuleName, 14, "invalid validator set")
-	ErrInvalidHeaderTimestamp  = errorsmod.Register(ModuleName, 15, "invalid header timestamp")
+	ErrInvalidChainID            = errorsmod.Register(ModuleName, 2, "invalid chain-id")
+	ErrInvalidTrustingPeriod     = errorsmod.Register(ModuleName, 3, "invalid trusting period")
+	ErrInvalidUnbondingPeriod    = errorsmod.Register(ModuleName, 4, "invalid unbonding period")
+	ErrInvalidHeaderHeight       = errorsmod.Register(ModuleName, 5, "invalid header height")
+	ErrInvalidHeader             = errorsmod.Register(ModuleName, 6, "invalid header")
+	ErrInvalidMaxClockDrift      = errorsmod.Register(ModuleName, 7, "invalid max clock drift")
+	ErrProcessedTimeNotFound     = errorsmod.Register(ModuleName, 8, "processed time not found")
+	ErrProcessedHeightNotFound   = errorsmod.Register(ModuleName, 9, "processed height not found")
+	ErrDelayPeriodNotPassed      = errorsmod.Register(ModuleName, 10, "packet-specified delay period has not been reached")
+	ErrTrustingPeriodExpired     = errorsmod.Register(ModuleName, 11, "time since latest trusted state has passed the trusting period")
+	ErrUnbondingPeriodExpired    = errorsmod.Register(ModuleName, 12, "time since latest trusted state has passed the unbonding period")
+	ErrInvalidProofSpecs         = errorsmod.Register(ModuleName, 13, "invalid proof specs")
+	ErrInvalidValidatorSet       = errorsmod.Register(ModuleName, 14, "invalid validator set")
+	ErrInvalidHeaderTimestamp    = errorsmod.Register(ModuleName, 15, "invalid header timestamp")
+	ErrMalformedProof            = errorsmod.Register(ModuleName, 16, "malformed commitment proof")
+	ErrProofValueMismatch        = errorsmod.Register(ModuleName, 17, "commitment proof does not match value")
+	ErrHistoricalInfoUnavailable = errorsmod.Register(ModuleName, 19, "historical info unavailable")
+	ErrEmptyPublicKeys           = errorsmod.Register(ModuleName, 20, "empty public key set")
+	ErrInvalidPublicKey          = errorsmod.Register(ModuleName, 21, "invalid public key")
+	ErrInvalidSignature          = errorsmod.Register(ModuleName, 22, "invalid signature")
+	ErrSignatureVerification     = errorsmod.Register(ModuleName, 23, "signature verification failed")
+	ErrContextDone               = errorsmod.Register(ModuleName, 24, "context cancelled or deadline exceeded")
+	ErrProofKeyPresent           = errorsmod.Register(ModuleName, 25, "commitment proof does not prove key absence")
+	ErrConsensusStateNotFound    = errorsmod.Register(ModuleName, 26, "consensus state not found")
+	ErrInsufficientVotingPower   = errorsmod.Register(ModuleName, 27, "insufficient voting power")
+	ErrNonMonotonicHeight        = errorsmod.Register(ModuleName, 29, "header height is not greater than the client latest height")
+	ErrInvalidPacketCommitment   = errorsmod.Register(ModuleName, 30, "invalid packet commitment")
 )
@@ -0,0 +1,75 @@
+package cometbls
+
+import (
+	"testing"
+	"time"
+
+	errorsmod "cosmossdk.io/errors"
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorsIs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sentinel error
+		errFn    func(t *testing.T) error
+	}{
+		{
+			"consensus state not found",
+			ErrConsensusStateNotFound,
+			func(t *testing.T) error {
+				clientState, _, header := newTestVerifiableHeader(t)
+				ctx := WithClientID(newTestContext(clientState.ChainId, 10), "cometbls-0")
+				return clientState.VerifyClientMessage(ctx, newTestCodec(), newTestClientStore(), header)
+			},
+		},
+		{
+			"invalid header timestamp",
+			ErrInvalidHeaderTimestamp,
+			func(t *testing.T) error {
+				clientState, consensusState, header := newTestVerifiableHeader(t)
+				consensusState.Timestamp = uint64(header.GetTime().Add(time.Second).UnixNano())
+
+				cdc := newTestCodec()
+				clientStore := newTestClientStore()
+				setConsensusState(clientStore, cdc, consensusState, header.TrustedHeight)
+
+				ctx := WithClientID(newTestContext(clientState.ChainId, 10), "cometbls-0")
+				return clientState.VerifyClientMessage(ctx, cdc, clientStore, header)
+			},
+		},
+		{
+			"historical info unavailable",
+			ErrHistoricalInfoUnavailable,
+			func(t *testing.T) error {
+				host := NewConsensusHost(historicalStakingKeeper{})
+				consensusStates, err := host.(*ConsensusHost).BatchGetSelfConsensusState(newTestContext(testChainID, 10), []exported.Height{
+					clienttypes.NewHeight(1, 5),
+				})
+				require.Nil(t, consensusStates)
+				return err
+			},
+		},
+		{
+			"insufficient voting power",
+			ErrInsufficientVotingPower,
+			func(t *testing.T) error {
+				valSet := []stakingtypes.Validator{newTestValidator(1, sdkmath.ZeroInt())}
+				_, err := validatorSetHash(valSet, sdk.DefaultPowerReduction)
+				return errorsmod.Wrap(err, "hashing the validator set")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.errFn(t)
+			require.ErrorIs(t, err, tc.sentinel)
+		})
+	}
+}
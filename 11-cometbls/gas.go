@@ -0,0 +1,27 @@
+package cometbls
+
+const (
+	// VerifyHeaderGas approximates the gas cost of verifying the zero knowledge proof of a header, dominated by
+	// the pairings of the Groth16 verifier.
+	VerifyHeaderGas uint64 = 500_000
+	// VerifyGasPerProofByte approximates the gas cost of decoding each byte of a zero knowledge proof.
+	VerifyGasPerProofByte uint64 = 10
+	// VerifyGasPerSigner approximates the gas cost attributed to each signer of the commit a header attests.
+	VerifyGasPerSigner uint64 = 2_000
+)
+
+// EstimateVerifyGas returns an approximation of the gas cost of verifying the header signed by the given number of
+// signers, so that relayers can size their fees before submitting it. The estimate is not charged by the client.
+// Headers do not carry their signers, the voting power is attested by the zero knowledge proof: the signer count is
+// the one of the commit the relayer built the proof from, a non-positive count adds nothing to the estimate.
+func EstimateVerifyGas(header *Header, signers int) uint64 {
+	if header == nil {
+		return 0
+	}
+
+	gas := VerifyHeaderGas + VerifyGasPerProofByte*uint64(len(header.ZeroKnowledgeProof))
+	if signers > 0 {
+		gas += VerifyGasPerSigner * uint64(signers)
+	}
+	return gas
+}
@@ -0,0 +1,35 @@
+package cometbls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateVerifyGas(t *testing.T) {
+	_, _, header := newTestVerifiableHeader(t)
+	estimate := EstimateVerifyGas(header, 1)
+	require.Equal(t, VerifyHeaderGas+VerifyGasPerProofByte*uint64(len(header.ZeroKnowledgeProof))+VerifyGasPerSigner, estimate)
+
+	// the estimate scales with the proof size
+	header.ZeroKnowledgeProof = append(header.ZeroKnowledgeProof, make([]byte, 100)...)
+	require.Equal(t, estimate+100*VerifyGasPerProofByte, EstimateVerifyGas(header, 1))
+
+	header.ZeroKnowledgeProof = nil
+	require.Equal(t, VerifyHeaderGas, EstimateVerifyGas(header, 0))
+	require.Equal(t, VerifyHeaderGas, EstimateVerifyGas(header, -1))
+	require.Zero(t, EstimateVerifyGas(nil, 1))
+}
+
+func TestEstimateVerifyGasSigners(t *testing.T) {
+	_, _, header := newTestVerifiableHeader(t)
+
+	// the estimate scales linearly with the signer count
+	previous := EstimateVerifyGas(header, 1)
+	for _, signers := range []int{2, 4, 64, MaxValidators} {
+		estimate := EstimateVerifyGas(header, signers)
+		require.Greater(t, estimate, previous)
+		require.Equal(t, uint64(signers-1)*VerifyGasPerSigner, estimate-EstimateVerifyGas(header, 1))
+		previous = estimate
+	}
+}
@@ -1,12 +1,22 @@
 package cometbls
 
 import (
+	errorsmod "cosmossdk.io/errors"
 	storetypes "cosmossdk.io/store/types"
 
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
 	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
 	"github.com/cosmos/ibc-go/v8/modules/core/exported"
 )
 
+// ConsensusStateWithHeight defines a consensus state with the height it is stored at.
+type ConsensusStateWithHeight struct {
+	Height         clienttypes.Height
+	ConsensusState *ConsensusState
+}
+
 // ExportMetadata exports all the consensus metadata in the client store so they can be included in clients genesis
 // and imported by a ClientKeeper
 func (ClientState) ExportMetadata(store storetypes.KVStore) []exported.GenesisMetadata {
@@ -20,3 +30,77 @@ func (ClientState) ExportMetadata(store storetypes.KVStore) []exported.GenesisMe
 	}
 	return gm
 }
+
+// ExportClientState exports the client state and all of its consensus states, in ascending height order,
+// stored in the client store.
+func ExportClientState(clientStore storetypes.KVStore, cdc codec.BinaryCodec) (*ClientState, []ConsensusStateWithHeight, error) {
+	clientState, found := getClientState(clientStore, cdc)
+	if !found {
+		return nil, nil, errorsmod.Wrap(clienttypes.ErrClientNotFound, "client state not found in client store")
+	}
+
+	var (
+		consensusStates []ConsensusStateWithHeight
+		err             error
+	)
+	IterateConsensusStateAscending(clientStore, func(height exported.Height) bool {
+		consensusState, found := GetConsensusState(clientStore, cdc, height)
+		if !found {
+			err = errorsmod.Wrapf(ErrConsensusStateNotFound, "height (%s)", height)
+			return true
+		}
+
+		consensusStates = append(consensusStates, ConsensusStateWithHeight{
+			Height:         height.(clienttypes.Height),
+			ConsensusState: consensusState,
+		})
+		return false
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return clientState, consensusStates, nil
+}
+
+// InitializeFromGenesis validates and stores an exported client state along with its consensus states.
+// The consensus states must be in strictly ascending height order, as ExportClientState exports them, and one of
+// them must be at the latest height of the client.
+func InitializeFromGenesis(
+	ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore,
+	clientState *ClientState, consensusStates []ConsensusStateWithHeight,
+) error {
+	if err := clientState.Validate(); err != nil {
+		return errorsmod.Wrap(clienttypes.ErrInvalidClient, err.Error())
+	}
+
+	var latestFound bool
+	for i, cs := range consensusStates {
+		if i > 0 && !consensusStates[i-1].Height.LT(cs.Height) {
+			return errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "consensus state at height %s must be above the previous height %s",
+				cs.Height, consensusStates[i-1].Height)
+		}
+		if cs.ConsensusState == nil {
+			return errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "consensus state at height %s cannot be nil", cs.Height)
+		}
+		if err := cs.ConsensusState.ValidateBasic(); err != nil {
+			return errorsmod.Wrapf(err, "consensus state at height %s", cs.Height)
+		}
+		if cs.Height.GT(clientState.GetLatestHeight()) {
+			return errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "consensus state height %s is greater than client latest height %s",
+				cs.Height, clientState.LatestHeight)
+		}
+		latestFound = latestFound || cs.Height.EQ(clientState.GetLatestHeight())
+	}
+	if !latestFound {
+		return errorsmod.Wrapf(ErrConsensusStateNotFound, "no consensus state at client latest height %s", clientState.LatestHeight)
+	}
+
+	setClientState(clientStore, cdc, clientState)
+	for _, cs := range consensusStates {
+		setConsensusState(clientStore, cdc, cs.ConsensusState, cs.Height)
+		setConsensusMetadata(ctx, clientStore, cs.Height)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package cometbls
+
+import (
+	"testing"
+
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConsensusStates(t *testing.T, heights ...uint64) []ConsensusStateWithHeight {
+	t.Helper()
+
+	consensusStates := make([]ConsensusStateWithHeight, len(heights))
+	for i, height := range heights {
+		consensusState, err := NewConsensusState(height, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+		require.NoError(t, err)
+		consensusStates[i] = ConsensusStateWithHeight{
+			Height:         clienttypes.NewHeight(1, height),
+			ConsensusState: consensusState,
+		}
+	}
+	return consensusStates
+}
+
+func TestExportImportClientState(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	clientState := newTestClientState()
+	consensusStates := newTestConsensusStates(t, 3, 4, 5)
+	require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, clientState, consensusStates))
+
+	exportedClientState, exportedConsensusStates, err := ExportClientState(clientStore, cdc)
+	require.NoError(t, err)
+	require.True(t, clientState.Equal(exportedClientState))
+	require.Len(t, exportedConsensusStates, 3)
+	for i, cs := range consensusStates {
+		require.Equal(t, cs.Height, exportedConsensusStates[i].Height)
+		require.True(t, cs.ConsensusState.Equal(exportedConsensusStates[i].ConsensusState))
+	}
+
+	// round trip into a fresh client store
+	importedClientStore := newTestClientStore()
+	require.NoError(t, InitializeFromGenesis(ctx, cdc, importedClientStore, exportedClientState, exportedConsensusStates))
+
+	reexportedClientState, reexportedConsensusStates, err := ExportClientState(importedClientStore, cdc)
+	require.NoError(t, err)
+	require.True(t, exportedClientState.Equal(reexportedClientState))
+	require.Equal(t, exportedConsensusStates, reexportedConsensusStates)
+}
+
+func TestInitializeFromGenesis(t *testing.T) {
+	testCases := []struct {
+		name            string
+		malleate        func(clientState *ClientState)
+		consensusStates []ConsensusStateWithHeight
+		expPass         bool
+	}{
+		{"valid genesis", func(_ *ClientState) {}, newTestConsensusStates(t, 4, 5), true},
+		{"invalid client state", func(cs *ClientState) { cs.TrustingPeriod = 0 }, newTestConsensusStates(t, 4, 5), false},
+		{"missing latest consensus state", func(_ *ClientState) {}, newTestConsensusStates(t, 3, 4), false},
+		{"consensus state above latest height", func(_ *ClientState) {}, newTestConsensusStates(t, 5, 6), false},
+		{"nil consensus state", func(_ *ClientState) {}, []ConsensusStateWithHeight{{Height: clienttypes.NewHeight(1, 5)}}, false},
+		{"duplicate heights", func(_ *ClientState) {}, newTestConsensusStates(t, 4, 4, 5), false},
+		{"duplicate latest height", func(_ *ClientState) {}, newTestConsensusStates(t, 4, 5, 5), false},
+		{"unsorted heights", func(_ *ClientState) {}, newTestConsensusStates(t, 5, 4), false},
+		{"unsorted heights below the latest height", func(_ *ClientState) {}, newTestConsensusStates(t, 3, 2, 5), false},
+		{"unsorted duplicate heights", func(_ *ClientState) {}, newTestConsensusStates(t, 4, 5, 4), false},
+		{"gapped heights", func(_ *ClientState) {}, newTestConsensusStates(t, 1, 3, 5), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientStore := newTestClientStore()
+			clientState := newTestClientState()
+			tc.malleate(clientState)
+
+			err := InitializeFromGenesis(newTestContext(testChainID, 10), newTestCodec(), clientStore, clientState, tc.consensusStates)
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				_, found := getClientState(clientStore, newTestCodec())
+				require.False(t, found)
+			}
+		})
+	}
+}
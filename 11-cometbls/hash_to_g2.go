@@ -0,0 +1,137 @@
+package cometbls
+
+import (
+	"math/big"
+
+	errorsmod "cosmossdk.io/errors"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+)
+
+// Parameters of the MiMC based expand_message_xmd of the CometBLS hash to G2, which expands a single field element
+// to the four base field elements of the two G2 points mapped to the curve.
+const (
+	xmdBlockBits   = 256
+	xmdOutputBytes = 192
+	xmdElementBits = 384
+	xmdElements    = 4
+)
+
+// hashToG2 hashes the message to G2 the way the light client circuit does (galoisd HashToG2): the message is first
+// hashed with MiMC, its bytes being read as a sequence of scalar field elements as the CometBLS vote sign bytes are,
+// then expanded with the MiMC based expand_message_xmd of RFC 9380 and mapped to the curve with the SvdW method. The
+// tag, CometblsSigDST, is read as a scalar field element as well.
+func hashToG2(message []byte) (curve.G2Affine, error) {
+	// short messages are left padded to a single element
+	if len(message) > fr.Bytes && len(message)%fr.Bytes != 0 {
+		return curve.G2Affine{}, errorsmod.Wrapf(ErrSignatureVerification, "message must be a sequence of %d bytes field elements, got %d bytes", fr.Bytes, len(message))
+	}
+
+	h := mimc.NewMiMC()
+	if _, err := h.Write(message); err != nil {
+		return curve.G2Affine{}, errorsmod.Wrapf(ErrSignatureVerification, "message must be a sequence of field elements: %s", err)
+	}
+
+	var msg, dst fr.Element
+	msg.SetBytes(h.Sum(nil))
+	dst.SetBytes([]byte(CometblsSigDST))
+
+	u := hashToG2Field(msg, dst)
+	q0 := curve.MapToCurve2(&curve.E2{A0: u[0], A1: u[1]})
+	q1 := curve.MapToCurve2(&curve.E2{A0: u[2], A1: u[3]})
+
+	var p0, p1 curve.G2Jac
+	p0.FromAffine(&q0)
+	p1.FromAffine(&q1).AddAssign(&p0)
+	p1.ClearCofactor(&p1)
+
+	var hashed curve.G2Affine
+	hashed.FromJacobian(&p1)
+	return hashed, nil
+}
+
+// hashToG2Field expands the message to the pseudo random bits of four base field elements, each element being made of
+// 384 bits so that it is close to uniform.
+func hashToG2Field(msg, dst fr.Element) [xmdElements]fp.Element {
+	bits := expandMsgXmd(msg, dst)
+
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), xmdElementBits), big.NewInt(1))
+
+	var elements [xmdElements]fp.Element
+	for i := range elements {
+		chunk := new(big.Int).Rsh(bits, uint(i*xmdElementBits))
+		elements[i].SetBigInt(chunk.And(chunk, mask))
+	}
+	return elements
+}
+
+// expandMsgXmd is the expand_message_xmd of RFC 9380 instantiated with MiMC, for a single field element message and
+// tag, expanding to 192 bytes. The circuit operates on bits rather than bytes: every value is written in its little
+// endian bit decomposition and the hash input is read by blocks of 256 bits, hence the returned bits are those of the
+// returned integer, from its least significant one.
+func expandMsgXmd(msg, dst fr.Element) *big.Int {
+	var (
+		block  = new(big.Int)
+		length uint
+	)
+	write := func(v *big.Int, bits uint) {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits), big.NewInt(1))
+		block.Or(block, new(big.Int).Lsh(new(big.Int).And(v, mask), length))
+		length += bits
+	}
+	writeU8 := func(v int64) {
+		write(big.NewInt(v), 8)
+	}
+	writeElement := func(e fr.Element) {
+		write(e.BigInt(new(big.Int)), xmdBlockBits)
+	}
+	writeDSTPrime := func() {
+		writeElement(dst)
+		writeU8(fr.Bytes)
+	}
+	sum := func() *big.Int {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), xmdBlockBits), big.NewInt(1))
+
+		// the blocks are field elements, reduced the way the circuit packs bits into a variable
+		var elems []fr.Element
+		for read := uint(0); read < length; read += xmdBlockBits {
+			var elem fr.Element
+			elem.SetBigInt(new(big.Int).And(new(big.Int).Rsh(block, read), mask))
+			elems = append(elems, elem)
+		}
+		block, length = new(big.Int), 0
+
+		b := mimcHash(elems...)
+		return b.BigInt(new(big.Int))
+	}
+
+	// b₀ = H(Z_pad ∥ msg ∥ l_i_b_str ∥ I2OSP(0, 1) ∥ DST_prime)
+	write(new(big.Int), xmdBlockBits)
+	writeElement(msg)
+	writeU8(xmdOutputBytes >> 8)
+	writeU8(xmdOutputBytes & 0xff)
+	writeU8(0)
+	writeDSTPrime()
+	b0 := sum()
+
+	// b₁ = H(b₀ ∥ I2OSP(1, 1) ∥ DST_prime)
+	write(b0, xmdBlockBits)
+	writeU8(1)
+	writeDSTPrime()
+	bi := sum()
+
+	out := new(big.Int).Set(bi)
+	for i := 1; i < xmdOutputBytes*8/xmdBlockBits; i++ {
+		// b_i = H(strxor(b₀, b_(i - 1)) ∥ I2OSP(i, 1) ∥ DST_prime)
+		write(new(big.Int).Xor(b0, bi), xmdBlockBits)
+		writeU8(int64(i + 1))
+		writeDSTPrime()
+		bi = sum()
+
+		out.Or(out, new(big.Int).Lsh(bi, uint(i*xmdBlockBits)))
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package cometbls
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashToG2(t *testing.T) {
+	// compressed images of the MiMC hash of the messages through the galoisd HashToG2 gadget, with CometblsSigDST
+	testCases := []struct {
+		name     string
+		message  []byte
+		expImage string
+	}{
+		{"empty message", nil, "82a66b34165ec54b2ba4311d6604b8801d39299b15f1419896a9b9a2e445b05324703cb42ac9799df542faed9e8d528c60618627e43be4d6e7981b39deb4343d"},
+		{"short message", []byte("cometbls"), "9f204441bb62d362f711f86523ec561227c7bee16a12343a76ea03104a648eb6054c4ff8628f18e1f33888fead3f418eeb04e4020a00c2ed88531f7c56da4c3a"},
+		{"field elements", make([]byte, 64), "98ff96455387d26421f27d9abe8f6edd1635085075b72c5a641ba944555325c725efd8de1e778b9a9ef4b7ddbcd3fa68ba51eb0be8305b037bf317487cbd12f7"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			image, err := hashToG2(tc.message)
+			require.NoError(t, err)
+			require.True(t, image.IsInSubGroup())
+
+			bz := image.Bytes()
+			require.Equal(t, tc.expImage, hex.EncodeToString(bz[:]))
+		})
+	}
+
+	// the message must be made of field elements
+	_, err := hashToG2(make([]byte, 33))
+	require.ErrorIs(t, err, ErrSignatureVerification)
+	_, err = hashToG2(bytes.Repeat([]byte{0xff}, 32))
+	require.ErrorIs(t, err, ErrSignatureVerification)
+}
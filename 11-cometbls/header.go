@@ -41,14 +41,25 @@ func (h Header) GetTime() time.Time {
 	return h.SignedHeader.Time
 }
 
-// ValidateBasic calls the SignedHeader ValidateBasic function and checks
-// that validatorsets are not nil.
+// ValidateBasic checks that the header is structurally valid before any proof or store access: the signed header
+// and trusted height must be set, the signed header must commit to a validator set and a next validator set, the
+// trusted height must be below the header height and the zero knowledge proof, which stands for the commit, must
+// not be empty.
 // NOTE: TrustedHeight and TrustedValidators may be empty when creating client
 // with MsgCreateClient
 func (h Header) ValidateBasic() error {
 	if h.SignedHeader == nil {
 		return errorsmod.Wrap(clienttypes.ErrInvalidHeader, "tendermint signed header cannot be nil")
 	}
+	if h.TrustedHeight == nil {
+		return errorsmod.Wrap(clienttypes.ErrInvalidHeader, "trusted height cannot be nil")
+	}
+	if len(h.SignedHeader.ValidatorsHash) == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidHeader, "signed header validators hash cannot be empty")
+	}
+	if len(h.SignedHeader.NextValidatorsHash) == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidHeader, "signed header next validators hash cannot be empty")
+	}
 
 	// TrustedHeight is less than Header for updates and misbehaviour
 	if h.TrustedHeight.GTE(h.GetHeight()) {
@@ -56,5 +67,9 @@ func (h Header) ValidateBasic() error {
 			h.TrustedHeight, h.GetHeight())
 	}
 
+	if len(h.ZeroKnowledgeProof) == 0 {
+		return errorsmod.Wrap(clienttypes.ErrInvalidHeader, "zero knowledge proof cannot be empty")
+	}
+
 	return nil
 }
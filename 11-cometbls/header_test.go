@@ -0,0 +1,51 @@
+package cometbls
+
+import (
+	"testing"
+	"time"
+
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderValidateBasic(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(header *Header)
+		expErr   error
+	}{
+		{"valid header", func(_ *Header) {}, nil},
+		{"nil signed header", func(header *Header) { header.SignedHeader = nil }, clienttypes.ErrInvalidHeader},
+		{"nil trusted height", func(header *Header) { header.TrustedHeight = nil }, clienttypes.ErrInvalidHeader},
+		{"empty validators hash", func(header *Header) { header.SignedHeader.ValidatorsHash = nil }, clienttypes.ErrInvalidHeader},
+		{"empty next validators hash", func(header *Header) { header.SignedHeader.NextValidatorsHash = nil }, clienttypes.ErrInvalidHeader},
+		{"trusted height at the header height", func(header *Header) { header.SignedHeader.Height = 5 }, ErrInvalidHeaderHeight},
+		{"trusted height above the header height", func(header *Header) { header.SignedHeader.Height = 4 }, ErrInvalidHeaderHeight},
+		{"empty zero knowledge proof", func(header *Header) { header.ZeroKnowledgeProof = nil }, clienttypes.ErrInvalidHeader},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := newTestHeader(6, 5, time.Unix(1710783278, 0))
+			tc.malleate(header)
+
+			err := header.ValidateBasic()
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyHeaderValidateBasic(t *testing.T) {
+	clientState, consensusState, header := newTestVerifiableHeader(t)
+	header.SignedHeader = nil
+
+	// structurally invalid headers are rejected before being dereferenced
+	require.NotPanics(t, func() {
+		err := VerifyHeader(newTestContext("union-devnet-1337", 10), clientState, consensusState, header)
+		require.ErrorIs(t, err, clienttypes.ErrInvalidHeader)
+	})
+}
@@ -0,0 +1,27 @@
+package cometbls
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+)
+
+// DefaultMerklePrefix is the commitment prefix under which ibc-go counterparty chains commit their IBC state.
+var DefaultMerklePrefix = commitmenttypes.NewMerklePrefix([]byte("ibc"))
+
+type merklePrefixKey struct{}
+
+// WithMerklePrefix returns a copy of the context carrying the commitment prefix of the counterparty chain, which
+// Initialize ties to the created client. The prefix is not a field of the ClientState as its protobuf type is fixed.
+func WithMerklePrefix(ctx sdk.Context, prefix commitmenttypes.MerklePrefix) sdk.Context {
+	return ctx.WithValue(merklePrefixKey{}, prefix)
+}
+
+// getContextMerklePrefix returns the commitment prefix attached to the context, or DefaultMerklePrefix if there is none.
+func getContextMerklePrefix(ctx sdk.Context) commitmenttypes.MerklePrefix {
+	prefix, ok := ctx.Value(merklePrefixKey{}).(commitmenttypes.MerklePrefix)
+	if !ok {
+		return DefaultMerklePrefix
+	}
+	return prefix
+}
@@ -45,3 +45,30 @@ func PruneExpiredConsensusStates(ctx sdk.Context, cdc codec.BinaryCodec, clientK
 
 	return totalPruned, nil
 }
+
+// MigrateStore migrates the consensus states of all cometbls clients from the given layout version to the current
+// one, see ibccomet.ConsensusStateVersion. This function may optionally be called during in-place store migrations.
+// The total number of consensus states migrated is returned.
+func MigrateStore(ctx sdk.Context, cdc codec.BinaryCodec, clientKeeper ClientKeeper, fromVersion int) (int, error) {
+	var clientIDs []string
+	clientKeeper.IterateClientStates(ctx, []byte(ibccomet.ClientType), func(clientID string, _ exported.ClientState) bool {
+		clientIDs = append(clientIDs, clientID)
+		return false
+	})
+
+	var totalMigrated int
+
+	for _, clientID := range clientIDs {
+		migrated, err := ibccomet.MigrateConsensusStates(clientKeeper.ClientStore(ctx, clientID), cdc, fromVersion)
+		if err != nil {
+			return 0, errorsmod.Wrapf(err, "clientID %s", clientID)
+		}
+
+		totalMigrated += migrated
+	}
+
+	clientLogger := clientKeeper.Logger(ctx)
+	clientLogger.Info("migrated cometbls consensus states", "total", totalMigrated, "from_version", fromVersion)
+
+	return totalMigrated, nil
+}
@@ -1,6 +1,7 @@
 package cometbls
 
 import (
+	"reflect"
 	"time"
 
 	errorsmod "cosmossdk.io/errors"
@@ -11,7 +12,8 @@ import (
 
 var _ exported.ClientMessage = (*Misbehaviour)(nil)
 
-// FrozenHeight is same for all misbehaviour
+// FrozenHeight is same for all misbehaviour. It is a sentinel to be compared against the zero height, a client
+// is frozen if its frozen height is not zero, and is at or below the latest height of any valid client.
 var FrozenHeight = clienttypes.NewHeight(0, 1)
 
 // NewMisbehaviour creates a new Misbehaviour instance.
@@ -38,6 +40,20 @@ func (misbehaviour Misbehaviour) GetTime() time.Time {
 	return t2
 }
 
+// IsTimeMonotonicityViolation returns true if the headers are at different heights, adjacent or not, and the header at
+// the higher height is not strictly later than the header at the lower height. Honest validators never sign such a
+// pair of headers. The headers may be given in any order.
+func IsTimeMonotonicityViolation(header1, header2 *Header) bool {
+	lower, higher := header1, header2
+	if higher.GetHeight().LT(lower.GetHeight()) {
+		lower, higher = higher, lower
+	}
+	if lower.GetHeight().EQ(higher.GetHeight()) {
+		return false
+	}
+	return !higher.GetTime().After(lower.GetTime())
+}
+
 // ValidateBasic implements Misbehaviour interface
 func (misbehaviour Misbehaviour) ValidateBasic() error {
 	if misbehaviour.Header_1 == nil {
@@ -46,14 +62,9 @@ func (misbehaviour Misbehaviour) ValidateBasic() error {
 	if misbehaviour.Header_2 == nil {
 		return errorsmod.Wrap(ErrInvalidHeader, "misbehaviour Header_2 cannot be nil")
 	}
-	if misbehaviour.Header_1.TrustedHeight.RevisionHeight == 0 {
-		return errorsmod.Wrapf(ErrInvalidHeaderHeight, "misbehaviour Header_1 cannot have zero revision height")
-	}
-	if misbehaviour.Header_2.TrustedHeight.RevisionHeight == 0 {
-		return errorsmod.Wrapf(ErrInvalidHeaderHeight, "misbehaviour Header_2 cannot have zero revision height")
-	}
 
-	// ValidateBasic on both validators
+	// ValidateBasic on both validators, rejecting headers missing their signed header or trusted height before
+	// either is read
 	if err := misbehaviour.Header_1.ValidateBasic(); err != nil {
 		return errorsmod.Wrap(
 			clienttypes.ErrInvalidMisbehaviour,
@@ -66,10 +77,28 @@ func (misbehaviour Misbehaviour) ValidateBasic() error {
 			errorsmod.Wrap(err, "header 2 failed validation").Error(),
 		)
 	}
+	if misbehaviour.Header_1.TrustedHeight.RevisionHeight == 0 {
+		return errorsmod.Wrapf(ErrInvalidHeaderHeight, "misbehaviour Header_1 cannot have zero revision height")
+	}
+	if misbehaviour.Header_2.TrustedHeight.RevisionHeight == 0 {
+		return errorsmod.Wrapf(ErrInvalidHeaderHeight, "misbehaviour Header_2 cannot have zero revision height")
+	}
 	// Ensure that Height1 is greater than or equal to Height2
 	if misbehaviour.Header_1.GetHeight().LT(misbehaviour.Header_2.GetHeight()) {
 		return errorsmod.Wrapf(clienttypes.ErrInvalidMisbehaviour, "Header_1 height is less than Header_2 height (%s < %s)", misbehaviour.Header_1.GetHeight(), misbehaviour.Header_2.GetHeight())
 	}
+	// Ensure that both headers are for the same client, the revision of a header being that of its trusted height
+	if misbehaviour.Header_1.TrustedHeight.RevisionNumber != misbehaviour.Header_2.TrustedHeight.RevisionNumber {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidMisbehaviour, "headers are for different revisions (%d != %d)", misbehaviour.Header_1.TrustedHeight.RevisionNumber, misbehaviour.Header_2.TrustedHeight.RevisionNumber)
+	}
+	// Ensure that the headers conflict, either as a fork at the same height or as a BFT time violation
+	if misbehaviour.Header_1.GetHeight().EQ(misbehaviour.Header_2.GetHeight()) {
+		if reflect.DeepEqual(misbehaviour.Header_1.SignedHeader, misbehaviour.Header_2.SignedHeader) {
+			return errorsmod.Wrap(clienttypes.ErrInvalidMisbehaviour, "headers are the same")
+		}
+	} else if !IsTimeMonotonicityViolation(misbehaviour.Header_1, misbehaviour.Header_2) {
+		return errorsmod.Wrap(clienttypes.ErrInvalidMisbehaviour, "headers at different heights do not violate time monotonicity")
+	}
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package cometbls
 
 import (
+	"bytes"
 	"reflect"
 
 	errorsmod "cosmossdk.io/errors"
@@ -16,6 +17,11 @@ import (
 // CheckForMisbehaviour detects duplicate height misbehaviour and BFT time violation misbehaviour
 // in a submitted Header message and verifies the correctness of a submitted Misbehaviour ClientMessage
 func (cs ClientState) CheckForMisbehaviour(ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore, msg exported.ClientMessage) bool {
+	// malformed client messages are rejected by VerifyClientMessage
+	if checkClientMessage(msg) != nil {
+		return false
+	}
+
 	switch msg := msg.(type) {
 	case *Header:
 		tmHeader := msg
@@ -27,7 +33,7 @@ func (cs ClientState) CheckForMisbehaviour(ctx sdk.Context, cdc codec.BinaryCode
 		if existingConsState, found := GetConsensusState(clientStore, cdc, tmHeader.GetHeight()); found {
 			// This header has already been submitted and the necessary state is already stored
 			// in client store, thus we can return early without further validation.
-			if reflect.DeepEqual(existingConsState, tmHeader.ConsensusState()) { //nolint:gosimple
+			if existingConsState.Equal(consState) {
 				return false
 			}
 
@@ -50,8 +56,19 @@ func (cs ClientState) CheckForMisbehaviour(ctx sdk.Context, cdc codec.BinaryCode
 			return true
 		}
 	case *Misbehaviour:
-		// we don't do anything here since we already verified any possible misbehaviour in `verifyMisbehaviour`
-		return true
+		// if heights are equal check that this is valid misbehaviour of a fork
+		// otherwise if heights are unequal check that this is valid misbehavior of BFT time violation
+		if msg.Header_1.GetHeight().EQ(msg.Header_2.GetHeight()) {
+			// Ensure that the committed states are different
+			if !bytes.Equal(msg.Header_1.SignedHeader.AppHash, msg.Header_2.SignedHeader.AppHash) ||
+				!bytes.Equal(msg.Header_1.SignedHeader.NextValidatorsHash, msg.Header_2.SignedHeader.NextValidatorsHash) {
+				return true
+			}
+		} else if IsTimeMonotonicityViolation(msg.Header_1, msg.Header_2) {
+			// the header at the greater height must not be later than the other header
+			// in order to be valid misbehaviour (violation of monotonic time).
+			return true
+		}
 	}
 
 	return false
@@ -76,7 +93,7 @@ func (cs *ClientState) verifyMisbehaviour(ctx sdk.Context, clientStore storetype
 		return errorsmod.Wrap(err, "verifying Header_2 in Misbehaviour failed")
 	}
 
-	if misbehaviour.Header_1.TrustedHeight == misbehaviour.Header_2.TrustedHeight {
+	if misbehaviour.Header_1.TrustedHeight.EQ(*misbehaviour.Header_2.TrustedHeight) {
 		if reflect.DeepEqual(misbehaviour.Header_1.SignedHeader, misbehaviour.Header_2.SignedHeader) {
 			return errorsmod.Wrap(clienttypes.ErrInvalidMisbehaviour, "headers are the same")
 		}
@@ -0,0 +1,221 @@
+package cometbls
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHeader returns a structurally valid header carrying a placeholder zero knowledge proof that never verifies.
+func newTestHeader(height int64, trustedHeight uint64, timestamp time.Time) *Header {
+	trusted := clienttypes.NewHeight(1, trustedHeight)
+	return &Header{
+		SignedHeader: &LightHeader{
+			Height:             height,
+			Time:               timestamp,
+			ValidatorsHash:     testNextValidatorsHash,
+			NextValidatorsHash: testNextValidatorsHash,
+			AppHash:            testAppHash,
+		},
+		TrustedHeight:      &trusted,
+		ZeroKnowledgeProof: []byte{0x01},
+	}
+}
+
+func TestCheckForMisbehaviourHeaders(t *testing.T) {
+	timestamp := time.Unix(1710783278, 0)
+
+	testCases := []struct {
+		name     string
+		malleate func(header1, header2 *Header)
+		expPass  bool
+	}{
+		{
+			"identical headers",
+			func(_, _ *Header) {},
+			false,
+		},
+		{
+			"conflicting app hash at the same height",
+			func(_, header2 *Header) {
+				header2.SignedHeader.AppHash = bytes.Repeat([]byte{0xcc}, 32)
+			},
+			true,
+		},
+		{
+			"conflicting next validators hash at the same height",
+			func(_, header2 *Header) {
+				header2.SignedHeader.NextValidatorsHash = bytes.Repeat([]byte{0xcc}, 32)
+			},
+			true,
+		},
+		{
+			"monotonic time at different heights",
+			func(header1, _ *Header) {
+				header1.SignedHeader.Height++
+				header1.SignedHeader.Time = timestamp.Add(time.Second)
+			},
+			false,
+		},
+		{
+			"time violation at non-adjacent heights",
+			func(header1, _ *Header) {
+				header1.SignedHeader.Height += 100
+				header1.SignedHeader.Time = timestamp
+			},
+			true,
+		},
+		{
+			"time violation at different heights",
+			func(header1, _ *Header) {
+				header1.SignedHeader.Height++
+				header1.SignedHeader.Time = timestamp.Add(-time.Second)
+			},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header1, header2 := newTestHeader(10, 5, timestamp), newTestHeader(10, 5, timestamp)
+			tc.malleate(header1, header2)
+
+			misbehaviour := NewMisbehaviour("", header1, header2)
+			if tc.expPass {
+				require.NoError(t, misbehaviour.ValidateBasic())
+			} else {
+				require.ErrorIs(t, misbehaviour.ValidateBasic(), clienttypes.ErrInvalidMisbehaviour)
+			}
+
+			found := newTestClientState().CheckForMisbehaviour(sdk.Context{}, nil, nil, misbehaviour)
+			require.Equal(t, tc.expPass, found)
+		})
+	}
+}
+
+func TestMisbehaviourValidateBasic(t *testing.T) {
+	timestamp := time.Unix(1710783278, 0)
+
+	testCases := []struct {
+		name     string
+		malleate func(header1, header2 *Header)
+		expErr   error
+	}{
+		{
+			"conflicting headers at the same height",
+			func(_, header2 *Header) {
+				header2.SignedHeader.AppHash = bytes.Repeat([]byte{0xcc}, 32)
+			},
+			nil,
+		},
+		{
+			"time violation at different heights",
+			func(header1, _ *Header) {
+				header1.SignedHeader.Height++
+			},
+			nil,
+		},
+		{
+			"identical headers",
+			func(_, _ *Header) {},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+		{
+			"monotonic time at different heights",
+			func(header1, _ *Header) {
+				header1.SignedHeader.Height++
+				header1.SignedHeader.Time = timestamp.Add(time.Second)
+			},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+		{
+			"headers for different revisions",
+			func(_, header2 *Header) {
+				trusted := clienttypes.NewHeight(2, 5)
+				header2.TrustedHeight = &trusted
+				header2.SignedHeader.AppHash = bytes.Repeat([]byte{0xcc}, 32)
+			},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+		{
+			"Header_1 missing its trusted height",
+			func(header1, _ *Header) {
+				header1.TrustedHeight = nil
+			},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+		{
+			"Header_2 missing its trusted height",
+			func(_, header2 *Header) {
+				header2.TrustedHeight = nil
+			},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+		{
+			"Header_1 missing its signed header",
+			func(header1, _ *Header) {
+				header1.SignedHeader = nil
+			},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+		{
+			"Header_1 below Header_2",
+			func(_, header2 *Header) {
+				header2.SignedHeader.Height++
+			},
+			clienttypes.ErrInvalidMisbehaviour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header1, header2 := newTestHeader(10, 5, timestamp), newTestHeader(10, 5, timestamp)
+			tc.malleate(header1, header2)
+
+			var err error
+			require.NotPanics(t, func() { err = NewMisbehaviour("", header1, header2).ValidateBasic() })
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyClientMessageInvalidMisbehaviour(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	header := newTestHeader(10, 5, time.Unix(1710783278, 0))
+
+	// identical headers are rejected before either header is verified against the client store
+	err := newTestClientState().VerifyClientMessage(ctx, newTestCodec(), newTestClientStore(), NewMisbehaviour("", header, header))
+	require.ErrorIs(t, err, clienttypes.ErrInvalidMisbehaviour)
+}
+
+func TestIsTimeMonotonicityViolation(t *testing.T) {
+	timestamp := time.Unix(1710783278, 0)
+
+	testCases := []struct {
+		name    string
+		header1 *Header
+		header2 *Header
+		expPass bool
+	}{
+		{"same height", newTestHeader(10, 5, timestamp), newTestHeader(10, 5, timestamp.Add(time.Second)), false},
+		{"monotonic time", newTestHeader(10, 5, timestamp), newTestHeader(20, 5, timestamp.Add(time.Second)), false},
+		{"lower height with a later time", newTestHeader(10, 5, timestamp.Add(time.Second)), newTestHeader(20, 5, timestamp), true},
+		{"lower height with the same time", newTestHeader(10, 5, timestamp), newTestHeader(20, 5, timestamp), true},
+		{"higher height first with an earlier time", newTestHeader(20, 5, timestamp), newTestHeader(10, 5, timestamp.Add(time.Second)), true},
+		{"higher height first with a later time", newTestHeader(20, 5, timestamp.Add(time.Second)), newTestHeader(10, 5, timestamp), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expPass, IsTimeMonotonicityViolation(tc.header1, tc.header2))
+		})
+	}
+}
@@ -49,7 +49,7 @@ func (cs ClientState) CheckSubstituteAndUpdateState(
 
 	consensusState, found := GetConsensusState(substituteClientStore, cdc, height)
 	if !found {
-		return errorsmod.Wrap(clienttypes.ErrConsensusStateNotFound, "unable to retrieve latest consensus state for substitute client")
+		return errorsmod.Wrap(ErrConsensusStateNotFound, "unable to retrieve latest consensus state for substitute client")
 	}
 
 	setConsensusState(subjectClientStore, cdc, consensusState, height)
@@ -96,3 +96,43 @@ func IsMatchingClientState(subject, substitute ClientState) bool {
 
 	return reflect.DeepEqual(subject, substitute)
 }
+
+// Unfreeze unfreezes a client frozen by misbehaviour once the incident has been resolved by governance. The
+// governance provided substitute consensus state is installed at the substitute height, which becomes the client
+// latest height if it is higher, and the resulting client state is validated before being stored.
+// An error is returned if the client is not frozen.
+func Unfreeze(
+	ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore,
+	clientState *ClientState, substituteConsState *ConsensusState, substituteHeight clienttypes.Height,
+) error {
+	if clientState.FrozenHeight.IsZero() {
+		return errorsmod.Wrap(clienttypes.ErrInvalidClient, "cannot unfreeze a client which is not frozen")
+	}
+
+	if substituteConsState == nil {
+		return errorsmod.Wrap(clienttypes.ErrInvalidConsensus, "substitute consensus state cannot be nil")
+	}
+	if err := substituteConsState.ValidateBasic(); err != nil {
+		return errorsmod.Wrap(err, "invalid substitute consensus state")
+	}
+	if substituteHeight.IsZero() {
+		return errorsmod.Wrap(clienttypes.ErrInvalidHeight, "substitute height cannot be zero")
+	}
+
+	unfrozen := clientState.Copy()
+	unfrozen.FrozenHeight = clienttypes.ZeroHeight()
+	if substituteHeight.GT(unfrozen.GetLatestHeight()) {
+		unfrozen.LatestHeight = substituteHeight
+	}
+	if err := unfrozen.Validate(); err != nil {
+		return errorsmod.Wrap(err, "invalid unfrozen client state")
+	}
+
+	setConsensusState(clientStore, cdc, substituteConsState, substituteHeight)
+	setConsensusMetadata(ctx, clientStore, substituteHeight)
+
+	*clientState = *unfrozen
+	setClientState(clientStore, cdc, clientState)
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package cometbls
+
+import (
+	"testing"
+
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnfreeze(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	substituteHeight := clienttypes.NewHeight(1, 8)
+	newSubstituteConsState := func() *ConsensusState {
+		consensusState, err := NewConsensusState(uint64(ctx.BlockTime().UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+		require.NoError(t, err)
+		return consensusState
+	}
+
+	testCases := []struct {
+		name     string
+		malleate func(clientState *ClientState, substituteConsState *ConsensusState) (*ConsensusState, clienttypes.Height)
+		expErr   error
+	}{
+		{
+			"frozen client is unfrozen",
+			func(_ *ClientState, substituteConsState *ConsensusState) (*ConsensusState, clienttypes.Height) {
+				return substituteConsState, substituteHeight
+			},
+			nil,
+		},
+		{
+			"client is not frozen",
+			func(clientState *ClientState, substituteConsState *ConsensusState) (*ConsensusState, clienttypes.Height) {
+				clientState.FrozenHeight = clienttypes.ZeroHeight()
+				return substituteConsState, substituteHeight
+			},
+			clienttypes.ErrInvalidClient,
+		},
+		{
+			"nil substitute consensus state",
+			func(_ *ClientState, _ *ConsensusState) (*ConsensusState, clienttypes.Height) {
+				return nil, substituteHeight
+			},
+			clienttypes.ErrInvalidConsensus,
+		},
+		{
+			"invalid substitute consensus state",
+			func(_ *ClientState, substituteConsState *ConsensusState) (*ConsensusState, clienttypes.Height) {
+				substituteConsState.Root = commitmenttypes.MerkleRoot{}
+				return substituteConsState, substituteHeight
+			},
+			clienttypes.ErrInvalidConsensus,
+		},
+		{
+			"zero substitute height",
+			func(_ *ClientState, substituteConsState *ConsensusState) (*ConsensusState, clienttypes.Height) {
+				return substituteConsState, clienttypes.ZeroHeight()
+			},
+			clienttypes.ErrInvalidHeight,
+		},
+		{
+			"substitute height in another revision",
+			func(_ *ClientState, substituteConsState *ConsensusState) (*ConsensusState, clienttypes.Height) {
+				return substituteConsState, clienttypes.NewHeight(2, 1)
+			},
+			ErrInvalidHeaderHeight,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			clientState.FrozenHeight = FrozenHeight
+			substituteConsState, height := tc.malleate(clientState, newSubstituteConsState())
+			setClientState(clientStore, cdc, clientState)
+			snapshot := snapshotStore(clientStore)
+
+			err := Unfreeze(ctx, cdc, clientStore, clientState, substituteConsState, height)
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				require.Equal(t, snapshot, snapshotStore(clientStore))
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, clientState.FrozenHeight.IsZero())
+			require.Equal(t, substituteHeight, clientState.LatestHeight)
+			require.Equal(t, clientState, getTestClientState(t, clientStore, cdc))
+			require.Equal(t, exported.Active, clientState.Status(ctx, clientStore, cdc))
+
+			consensusState, found := GetConsensusState(clientStore, cdc, substituteHeight)
+			require.True(t, found)
+			require.Equal(t, substituteConsState, consensusState)
+
+			processedHeight, found := GetProcessedHeight(clientStore, substituteHeight)
+			require.True(t, found)
+			require.Equal(t, clienttypes.GetSelfHeight(ctx), processedHeight)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package cometbls
+
+import (
+	errorsmod "cosmossdk.io/errors"
+
+	ics23 "github.com/cosmos/ics23/go"
+
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	ibcerrors "github.com/cosmos/ibc-go/v8/modules/core/errors"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+)
+
+// VerifyMembershipWithRoot verifies a protobuf encoded ICS 23 commitment merkle proof of the existence of a value at
+// a given path against a raw commitment root and the given proof specs, e.g. the ones of ClientState.GetProofSpecs.
+// It neither requires a client state nor access to a client store, so that tooling such as relayer simulations can
+// verify proofs from raw bytes.
+// An ErrInvalidProofSpecs is returned if the proof specs are empty or contain a nil spec, an ErrMalformedProof if
+// the proof cannot be decoded and an ErrProofValueMismatch if it does not commit to the value.
+func VerifyMembershipWithRoot(root []byte, proofSpecs []*ics23.ProofSpec, path exported.Path, proof, value []byte) error {
+	if err := validateProofSpecs(proofSpecs); err != nil {
+		return err
+	}
+
+	return verifyMembership(proofSpecs, commitmenttypes.NewMerkleRoot(root), path, proof, value)
+}
+
+// verifyMembership verifies a protobuf encoded ICS 23 commitment merkle proof against the root with the given proof specs.
+func verifyMembership(proofSpecs []*ics23.ProofSpec, root exported.Root, path exported.Path, proof []byte, value []byte) error {
+	merkleProof, merklePath, err := decodeMerkleProof(path, proof)
+	if err != nil {
+		return err
+	}
+
+	if err := merkleProof.VerifyMembership(proofSpecs, root, merklePath, value); err != nil {
+		return errorsmod.Wrap(ErrProofValueMismatch, err.Error())
+	}
+
+	return nil
+}
+
+// decodeMerkleProof decodes a protobuf encoded ICS 23 commitment merkle proof along with the merkle path it is verified
+// at. An ErrMalformedProof is returned if the proof cannot be decoded or is empty.
+func decodeMerkleProof(path exported.Path, proof []byte) (commitmenttypes.MerkleProof, commitmenttypes.MerklePath, error) {
+	var merkleProof commitmenttypes.MerkleProof
+	if err := merkleProof.Unmarshal(proof); err != nil {
+		return commitmenttypes.MerkleProof{}, commitmenttypes.MerklePath{}, errorsmod.Wrap(ErrMalformedProof, "failed to unmarshal proof into ICS 23 commitment merkle proof")
+	}
+	if merkleProof.Empty() {
+		return commitmenttypes.MerkleProof{}, commitmenttypes.MerklePath{}, errorsmod.Wrap(ErrMalformedProof, "proof cannot be empty")
+	}
+
+	merklePath, ok := path.(commitmenttypes.MerklePath)
+	if !ok {
+		return commitmenttypes.MerkleProof{}, commitmenttypes.MerklePath{}, errorsmod.Wrapf(ibcerrors.ErrInvalidType, "expected %T, got %T", commitmenttypes.MerklePath{}, path)
+	}
+
+	return merkleProof, merklePath, nil
+}
@@ -0,0 +1,46 @@
+package cometbls
+
+import (
+	"testing"
+
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	ics23 "github.com/cosmos/ics23/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyMembershipWithRoot(t *testing.T) {
+	key, value := []byte("clients/07-tendermint-0/clientState"), []byte("value")
+	root, path, proof := newTestProof(t, key, value, key)
+
+	testCases := []struct {
+		name       string
+		root       []byte
+		proofSpecs []*ics23.ProofSpec
+		path       commitmenttypes.MerklePath
+		proof      []byte
+		value      []byte
+		expErr     error
+	}{
+		{"valid proof", root.GetHash(), commitmenttypes.GetSDKSpecs(), path, proof, value, nil},
+		{"valid proof with the client proof specs", root.GetHash(), newTestClientState().GetProofSpecs(), path, proof, value, nil},
+		{"empty proof specs", root.GetHash(), nil, path, proof, value, ErrInvalidProofSpecs},
+		{"nil proof spec", root.GetHash(), []*ics23.ProofSpec{ics23.IavlSpec, nil}, path, proof, value, ErrInvalidProofSpecs},
+		{"mismatched proof specs", root.GetHash(), []*ics23.ProofSpec{ics23.TendermintSpec, ics23.IavlSpec}, path, proof, value, ErrProofValueMismatch},
+		{"wrong root", make([]byte, len(root.GetHash())), commitmenttypes.GetSDKSpecs(), path, proof, value, ErrProofValueMismatch},
+		{"wrong value", root.GetHash(), commitmenttypes.GetSDKSpecs(), path, proof, []byte("other"), ErrProofValueMismatch},
+		{"wrong path", root.GetHash(), commitmenttypes.GetSDKSpecs(), commitmenttypes.NewMerklePath(testStoreKey, "other"), proof, value, ErrProofValueMismatch},
+		{"malformed proof", root.GetHash(), commitmenttypes.GetSDKSpecs(), path, []byte("proof"), value, ErrMalformedProof},
+		{"empty proof", root.GetHash(), commitmenttypes.GetSDKSpecs(), path, nil, value, ErrMalformedProof},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyMembershipWithRoot(tc.root, tc.proofSpecs, tc.path, tc.proof, tc.value)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
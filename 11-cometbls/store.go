@@ -3,15 +3,21 @@ package cometbls
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"io"
 	"strings"
 
+	errorsmod "cosmossdk.io/errors"
+	"cosmossdk.io/store/cachekv"
 	"cosmossdk.io/store/prefix"
+	"cosmossdk.io/store/tracekv"
 	storetypes "cosmossdk.io/store/types"
 
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
 	host "github.com/cosmos/ibc-go/v8/modules/core/24-host"
 	"github.com/cosmos/ibc-go/v8/modules/core/exported"
 )
@@ -42,6 +48,10 @@ var (
 	KeyProcessedHeight = []byte("/processedHeight")
 	// KeyIteration stores the key mapping to consensus state key for efficient iteration
 	KeyIteration = []byte("/iterationKey")
+	// KeyUpdateCount stores the number of headers the client was updated with
+	KeyUpdateCount = []byte("updateCount")
+	// KeyMerklePrefix stores the commitment prefix of the counterparty chain the client was created with
+	KeyMerklePrefix = []byte("merklePrefix")
 )
 
 // setClientState stores the client state
@@ -51,6 +61,19 @@ func setClientState(clientStore storetypes.KVStore, cdc codec.BinaryCodec, clien
 	clientStore.Set(key, val)
 }
 
+// getClientState retrieves the client state from the client prefixed store.
+// If the ClientState does not exist in state a nil value and false boolean flag is returned
+func getClientState(clientStore storetypes.KVStore, cdc codec.BinaryCodec) (*ClientState, bool) {
+	bz := clientStore.Get(host.ClientStateKey())
+	if len(bz) == 0 {
+		return nil, false
+	}
+
+	clientStateI := clienttypes.MustUnmarshalClientState(cdc, bz)
+	clientState, ok := clientStateI.(*ClientState)
+	return clientState, ok
+}
+
 // setConsensusState stores the consensus state at the given height.
 func setConsensusState(clientStore storetypes.KVStore, cdc codec.BinaryCodec, consensusState *ConsensusState, height exported.Height) {
 	key := host.ConsensusStateKey(height)
@@ -143,6 +166,42 @@ func deleteProcessedTime(clientStore storetypes.KVStore, height exported.Height)
 	clientStore.Delete(key)
 }
 
+// GetUpdateCount returns the number of headers the client was updated with. Operators may use it to detect stalled
+// clients. Duplicate headers and headers freezing the client are not counted.
+func GetUpdateCount(clientStore storetypes.KVStore) uint64 {
+	bz := clientStore.Get(KeyUpdateCount)
+	if len(bz) == 0 {
+		return 0
+	}
+	return sdk.BigEndianToUint64(bz)
+}
+
+// incrementUpdateCount increments the number of headers the client was updated with.
+func incrementUpdateCount(clientStore storetypes.KVStore) {
+	clientStore.Set(KeyUpdateCount, sdk.Uint64ToBigEndian(GetUpdateCount(clientStore)+1))
+}
+
+// GetMerklePrefix returns the commitment prefix the client was created with, or DefaultMerklePrefix for the clients
+// created before the prefix was stored.
+func GetMerklePrefix(clientStore storetypes.KVStore) commitmenttypes.MerklePrefix {
+	bz := clientStore.Get(KeyMerklePrefix)
+	if bz == nil {
+		return DefaultMerklePrefix
+	}
+	return commitmenttypes.NewMerklePrefix(bz)
+}
+
+// setMerklePrefix validates and stores the commitment prefix of the client.
+// An ErrInvalidPrefix is returned if the prefix is empty.
+func setMerklePrefix(clientStore storetypes.KVStore, prefix commitmenttypes.MerklePrefix) error {
+	if prefix.Empty() {
+		return errorsmod.Wrap(commitmenttypes.ErrInvalidPrefix, "commitment prefix cannot be empty")
+	}
+
+	clientStore.Set(KeyMerklePrefix, prefix.Bytes())
+	return nil
+}
+
 // ProcessedHeightKey returns the key under which the processed height will be stored in the client store.
 func ProcessedHeightKey(height exported.Height) []byte {
 	return append(host.ConsensusStateKey(height), KeyProcessedHeight...)
@@ -215,6 +274,14 @@ func GetHeightFromIterationKey(iterKey []byte) exported.Height {
 	return clienttypes.NewHeight(revision, height)
 }
 
+// IterateConsensusStateHeights visits the height of every stored consensus state in ascending order, until the
+// callback returns false.
+func IterateConsensusStateHeights(clientStore storetypes.KVStore, cb func(height clienttypes.Height) bool) {
+	IterateConsensusStateAscending(clientStore, func(height exported.Height) bool {
+		return !cb(height.(clienttypes.Height))
+	})
+}
+
 // IterateConsensusStateAscending iterates through the consensus states in ascending order. It calls the provided
 // callback on each height, until stop=true is returned.
 func IterateConsensusStateAscending(clientStore storetypes.KVStore, cb func(height exported.Height) (stop bool)) {
@@ -273,9 +340,24 @@ func GetPreviousConsensusState(clientStore storetypes.KVStore, cdc codec.BinaryC
 	return getTmConsensusState(clientStore, cdc, csKey)
 }
 
+// GetLatestConsensusStateHeight returns the highest height at which a consensus state is stored.
+// The boolean flag is false if the client store does not contain any consensus state.
+func GetLatestConsensusStateHeight(clientStore storetypes.KVStore) (clienttypes.Height, bool) {
+	iterator := storetypes.KVStoreReversePrefixIterator(clientStore, []byte(KeyIterateConsensusStatePrefix))
+	defer iterator.Close()
+
+	if !iterator.Valid() {
+		return clienttypes.ZeroHeight(), false
+	}
+
+	return GetHeightFromIterationKey(iterator.Key()).(clienttypes.Height), true
+}
+
 // PruneAllExpiredConsensusStates iterates over all consensus states for a given
 // client store. If a consensus state is expired, it is deleted and its metadata
-// is deleted. The number of consensus states pruned is returned.
+// is deleted. Unlike PruneConsensusStates, the consensus state at the client
+// latest height is pruned as well once expired. The number of consensus states
+// pruned is returned.
 func PruneAllExpiredConsensusStates(
 	ctx sdk.Context, clientStore storetypes.KVStore,
 	cdc codec.BinaryCodec, clientState *ClientState,
@@ -288,7 +370,7 @@ func PruneAllExpiredConsensusStates(
 			return true
 		}
 
-		if clientState.IsExpired(consState.Timestamp, uint64(ctx.BlockTime().UnixNano())) {
+		if clientState.IsExpired(consState.GetTime(), ctx.BlockTime()) {
 			heights = append(heights, height)
 		}
 
@@ -305,6 +387,101 @@ func PruneAllExpiredConsensusStates(
 	return len(heights)
 }
 
+// PruneConsensusStates deletes the expired consensus states of the client along with their metadata,
+// except for the consensus state at the client latest height which is always retained.
+// At most maxPrune consensus states are deleted per call so that pruning can be spread over several blocks,
+// a non-positive maxPrune deletes every expired consensus state.
+// The number of consensus states pruned is returned, along with whether expired consensus states remain.
+func PruneConsensusStates(
+	ctx sdk.Context, clientStore storetypes.KVStore,
+	cdc codec.BinaryCodec, clientState *ClientState, maxPrune int,
+) (int, bool, error) {
+	var (
+		heights []exported.Height
+		more    bool
+		err     error
+	)
+
+	pruneCb := func(height exported.Height) bool {
+		if height.EQ(clientState.GetLatestHeight()) {
+			return false
+		}
+
+		consState, found := GetConsensusState(clientStore, cdc, height)
+		if !found {
+			err = errorsmod.Wrapf(ErrConsensusStateNotFound, "failed to retrieve consensus state at height: %s", height)
+			return true
+		}
+
+		if clientState.IsExpired(consState.GetTime(), ctx.BlockTime()) {
+			if maxPrune > 0 && len(heights) == maxPrune {
+				more = true
+				return true
+			}
+			heights = append(heights, height)
+		}
+
+		return false
+	}
+
+	IterateConsensusStateAscending(clientStore, pruneCb)
+	if err != nil {
+		return 0, false, err
+	}
+
+	for _, height := range heights {
+		deleteConsensusState(clientStore, height)
+		deleteConsensusMetadata(clientStore, height)
+	}
+
+	return len(heights), more, nil
+}
+
+// MigrateConsensusStates migrates every consensus state of the client store from the given layout version to the
+// current one with MigrateConsensusState. Consensus metadata is left untouched. Nothing is written if any consensus
+// state fails to migrate. The number of consensus states rewritten is returned.
+func MigrateConsensusStates(clientStore storetypes.KVStore, cdc codec.BinaryCodec, fromVersion int) (int, error) {
+	if fromVersion == ConsensusStateVersion {
+		return 0, nil
+	}
+
+	var (
+		heights  []exported.Height
+		migrated []*ConsensusState
+		err      error
+	)
+
+	migrateCb := func(height exported.Height) bool {
+		consState, found := GetConsensusState(clientStore, cdc, height)
+		if !found {
+			err = errorsmod.Wrapf(ErrConsensusStateNotFound, "failed to retrieve consensus state at height: %s", height)
+			return true
+		}
+
+		var migratedState *ConsensusState
+		migratedState, err = MigrateConsensusState(consState, fromVersion)
+		if err != nil {
+			err = errorsmod.Wrapf(err, "height %s", height)
+			return true
+		}
+
+		heights = append(heights, height)
+		migrated = append(migrated, migratedState)
+		return false
+	}
+
+	IterateConsensusStateAscending(clientStore, migrateCb)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, height := range heights {
+		setConsensusState(clientStore, cdc, migrated[i], height)
+	}
+
+	return len(heights), nil
+}
+
 // Helper function for GetNextConsensusState and GetPreviousConsensusState
 func getTmConsensusState(clientStore storetypes.KVStore, cdc codec.BinaryCodec, key []byte) (*ConsensusState, bool) {
 	bz := clientStore.Get(key)
@@ -356,3 +533,39 @@ func deleteConsensusMetadata(clientStore storetypes.KVStore, height exported.Hei
 	deleteProcessedHeight(clientStore, height)
 	deleteIterationKey(clientStore, height)
 }
+
+var _ storetypes.KVStore = readOnlyStore{}
+
+// readOnlyStore is a client store view panicking on writes, guarding verification-only flows against accidental
+// store writes. Writes to a cache wrapped read-only store panic once the cache is written.
+type readOnlyStore struct {
+	storetypes.KVStore
+}
+
+// NewReadOnlyStore returns a read-only view of the client store, writes through it panic.
+func NewReadOnlyStore(clientStore storetypes.KVStore) storetypes.KVStore {
+	if store, ok := clientStore.(readOnlyStore); ok {
+		return store
+	}
+	return readOnlyStore{KVStore: clientStore}
+}
+
+// Set implements storetypes.KVStore, it always panics.
+func (readOnlyStore) Set(key, _ []byte) {
+	panic(fmt.Sprintf("cannot set key %X in a read-only client store", key))
+}
+
+// Delete implements storetypes.KVStore, it always panics.
+func (readOnlyStore) Delete(key []byte) {
+	panic(fmt.Sprintf("cannot delete key %X in a read-only client store", key))
+}
+
+// CacheWrap implements storetypes.KVStore, wrapping the read-only store itself so that writing the cache panics.
+func (s readOnlyStore) CacheWrap() storetypes.CacheWrap {
+	return cachekv.NewStore(s)
+}
+
+// CacheWrapWithTrace implements storetypes.KVStore, wrapping the read-only store itself so that writing the cache panics.
+func (s readOnlyStore) CacheWrapWithTrace(w io.Writer, tc storetypes.TraceContext) storetypes.CacheWrap {
+	return cachekv.NewStore(tracekv.NewStore(s, w, tc))
+}
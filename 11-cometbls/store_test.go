@@ -0,0 +1,279 @@
+package cometbls
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	storetypes "cosmossdk.io/store/types"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneConsensusStates(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	// consensus states timestamps are their heights, the trusting period is 100
+	clientState := newTestClientState()
+	clientState.LatestHeight = clienttypes.NewHeight(1, 120)
+	require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, clientState, newTestConsensusStates(t, 10, 20, 110, 120)))
+
+	// 10 and 20 are expired, 110 is fresh and 120 is the latest
+	ctx = ctx.WithBlockTime(time.Unix(0, 150))
+	pruned, more, err := PruneConsensusStates(ctx, clientStore, cdc, clientState, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, pruned)
+	require.False(t, more)
+
+	for _, height := range []uint64{10, 20} {
+		_, found := GetConsensusState(clientStore, cdc, clienttypes.NewHeight(1, height))
+		require.False(t, found)
+		_, found = GetProcessedTime(clientStore, clienttypes.NewHeight(1, height))
+		require.False(t, found)
+		require.Nil(t, GetIterationKey(clientStore, clienttypes.NewHeight(1, height)))
+	}
+	for _, height := range []uint64{110, 120} {
+		_, found := GetConsensusState(clientStore, cdc, clienttypes.NewHeight(1, height))
+		require.True(t, found)
+	}
+
+	// every consensus state is expired, the latest one must be retained
+	ctx = ctx.WithBlockTime(time.Unix(0, 1000))
+	pruned, more, err = PruneConsensusStates(ctx, clientStore, cdc, clientState, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+	require.False(t, more)
+
+	_, found := GetConsensusState(clientStore, cdc, clientState.LatestHeight)
+	require.True(t, found)
+}
+
+func TestPruneAllExpiredConsensusStates(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	// consensus states timestamps are their heights, the trusting period is 100
+	clientState := newTestClientState()
+	clientState.LatestHeight = clienttypes.NewHeight(1, 120)
+	require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, clientState, newTestConsensusStates(t, 10, 20, 110, 120)))
+
+	// 10 and 20 are expired, 110 is fresh and 120 is the latest
+	ctx = ctx.WithBlockTime(time.Unix(0, 150))
+	require.Equal(t, 2, PruneAllExpiredConsensusStates(ctx, clientStore, cdc, clientState))
+
+	for _, height := range []uint64{110, 120} {
+		_, found := GetConsensusState(clientStore, cdc, clienttypes.NewHeight(1, height))
+		require.True(t, found)
+	}
+
+	// every consensus state is expired, unlike PruneConsensusStates the latest one is pruned as well
+	ctx = ctx.WithBlockTime(time.Unix(0, 1000))
+	require.Equal(t, 2, PruneAllExpiredConsensusStates(ctx, clientStore, cdc, clientState))
+
+	_, found := GetConsensusState(clientStore, cdc, clientState.LatestHeight)
+	require.False(t, found)
+	_, found = GetProcessedTime(clientStore, clientState.LatestHeight)
+	require.False(t, found)
+	require.Nil(t, GetIterationKey(clientStore, clientState.LatestHeight))
+}
+
+func TestPruneConsensusStatesIncrementally(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	// consensus states timestamps are their heights, the trusting period is 100
+	clientState := newTestClientState()
+	clientState.LatestHeight = clienttypes.NewHeight(1, 200)
+	require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, clientState, newTestConsensusStates(t, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 200)))
+
+	// the 10 consensus states below the latest height are expired
+	ctx = ctx.WithBlockTime(time.Unix(0, 150))
+	pruned, more, err := PruneConsensusStates(ctx, clientStore, cdc, clientState, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, pruned)
+	require.True(t, more)
+
+	// the lowest heights are pruned first
+	for height := uint64(1); height <= 10; height++ {
+		_, found := GetConsensusState(clientStore, cdc, clienttypes.NewHeight(1, height))
+		require.Equal(t, height > 3, found, height)
+	}
+
+	for _, expPruned := range []int{3, 3} {
+		pruned, more, err = PruneConsensusStates(ctx, clientStore, cdc, clientState, 3)
+		require.NoError(t, err)
+		require.Equal(t, expPruned, pruned)
+		require.True(t, more)
+	}
+
+	// the last expired consensus state is pruned and none remain
+	pruned, more, err = PruneConsensusStates(ctx, clientStore, cdc, clientState, 3)
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+	require.False(t, more)
+
+	pruned, more, err = PruneConsensusStates(ctx, clientStore, cdc, clientState, 3)
+	require.NoError(t, err)
+	require.Zero(t, pruned)
+	require.False(t, more)
+}
+
+func TestGetLatestConsensusStateHeight(t *testing.T) {
+	clientStore := newTestClientStore()
+
+	_, found := GetLatestConsensusStateHeight(clientStore)
+	require.False(t, found)
+
+	// the consensus states are stored out of order
+	ctx, cdc := newTestContext(testChainID, 10), newTestCodec()
+	for _, cs := range newTestConsensusStates(t, 300, 2, 10) {
+		setConsensusState(clientStore, cdc, cs.ConsensusState, cs.Height)
+		setConsensusMetadata(ctx, clientStore, cs.Height)
+	}
+
+	height, found := GetLatestConsensusStateHeight(clientStore)
+	require.True(t, found)
+	require.Equal(t, clienttypes.NewHeight(1, 300), height)
+}
+
+func TestIterateConsensusStateHeights(t *testing.T) {
+	clientStore := newTestClientStore()
+	// the consensus states are stored out of order
+	ctx, cdc := newTestContext(testChainID, 10), newTestCodec()
+	for _, cs := range newTestConsensusStates(t, 300, 2, 10, 256) {
+		setConsensusState(clientStore, cdc, cs.ConsensusState, cs.Height)
+		setConsensusMetadata(ctx, clientStore, cs.Height)
+	}
+
+	var heights []clienttypes.Height
+	IterateConsensusStateHeights(clientStore, func(height clienttypes.Height) bool {
+		heights = append(heights, height)
+		return true
+	})
+	require.Equal(t, []clienttypes.Height{
+		clienttypes.NewHeight(1, 2), clienttypes.NewHeight(1, 10), clienttypes.NewHeight(1, 256), clienttypes.NewHeight(1, 300),
+	}, heights)
+
+	heights = nil
+	IterateConsensusStateHeights(clientStore, func(height clienttypes.Height) bool {
+		heights = append(heights, height)
+		return len(heights) < 2
+	})
+	require.Equal(t, []clienttypes.Height{clienttypes.NewHeight(1, 2), clienttypes.NewHeight(1, 10)}, heights)
+}
+
+func TestProcessedMetadata(t *testing.T) {
+	clientStore := newTestClientStore()
+	height := clienttypes.NewHeight(1, 5)
+
+	_, found := GetProcessedTime(clientStore, height)
+	require.False(t, found)
+	_, found = GetProcessedHeight(clientStore, height)
+	require.False(t, found)
+
+	SetProcessedTime(clientStore, height, 100)
+	SetProcessedHeight(clientStore, height, clienttypes.NewHeight(1, 10))
+
+	processedTime, found := GetProcessedTime(clientStore, height)
+	require.True(t, found)
+	require.Equal(t, uint64(100), processedTime)
+
+	processedHeight, found := GetProcessedHeight(clientStore, height)
+	require.True(t, found)
+	require.Equal(t, clienttypes.NewHeight(1, 10), processedHeight)
+
+	// metadata is keyed by consensus height
+	_, found = GetProcessedTime(clientStore, clienttypes.NewHeight(1, 6))
+	require.False(t, found)
+}
+
+func TestReadOnlyStore(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	clientState := newTestClientState()
+	consensusState, err := NewConsensusState(uint64(ctx.BlockTime().UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+	require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+	readOnlyStore := NewReadOnlyStore(clientStore)
+	require.Equal(t, readOnlyStore, NewReadOnlyStore(readOnlyStore))
+
+	// reads succeed
+	storedConsensusState, found := GetConsensusState(readOnlyStore, cdc, clientState.LatestHeight)
+	require.True(t, found)
+	require.True(t, consensusState.Equal(storedConsensusState))
+	require.Equal(t, exported.Active, clientState.Status(ctx, readOnlyStore, cdc))
+	timestamp, err := clientState.GetTimestampAtHeight(ctx, readOnlyStore, cdc, clientState.LatestHeight)
+	require.NoError(t, err)
+	require.Equal(t, consensusState.GetTimestamp(), timestamp)
+
+	// writes panic, including writes through a cache of the store
+	require.Panics(t, func() { setConsensusState(readOnlyStore, cdc, consensusState, clienttypes.NewHeight(1, 6)) })
+	require.Panics(t, func() { deleteConsensusState(readOnlyStore, clientState.LatestHeight) })
+
+	cache := readOnlyStore.CacheWrap().(storetypes.CacheKVStore)
+	cache.Set([]byte("key"), []byte("value"))
+	require.Panics(t, cache.Write)
+
+	_, found = GetConsensusState(clientStore, cdc, clienttypes.NewHeight(1, 6))
+	require.False(t, found)
+}
+
+func TestMigrateConsensusStates(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+
+	// consensus states timestamps are their heights, i.e. seconds in the legacy layout
+	heights := []uint64{3, 4, 5}
+
+	t.Run("legacy to current", func(t *testing.T) {
+		clientStore := newTestClientStore()
+		require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, newTestClientState(), newTestConsensusStates(t, heights...)))
+
+		migrated, err := MigrateConsensusStates(clientStore, cdc, ConsensusStateVersionLegacy)
+		require.NoError(t, err)
+		require.Equal(t, len(heights), migrated)
+
+		for _, height := range heights {
+			consState, found := GetConsensusState(clientStore, cdc, clienttypes.NewHeight(1, height))
+			require.True(t, found)
+			require.Equal(t, time.Unix(int64(height), 0).UTC(), consState.GetTime())
+
+			_, found = GetProcessedTime(clientStore, clienttypes.NewHeight(1, height))
+			require.True(t, found)
+		}
+	})
+
+	t.Run("already current", func(t *testing.T) {
+		clientStore := newTestClientStore()
+		require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, newTestClientState(), newTestConsensusStates(t, heights...)))
+		before := snapshotStore(clientStore)
+
+		migrated, err := MigrateConsensusStates(clientStore, cdc, ConsensusStateVersion)
+		require.NoError(t, err)
+		require.Zero(t, migrated)
+		require.Equal(t, before, snapshotStore(clientStore))
+	})
+
+	t.Run("failed migration writes nothing", func(t *testing.T) {
+		clientStore := newTestClientStore()
+		require.NoError(t, InitializeFromGenesis(ctx, cdc, clientStore, newTestClientState(), newTestConsensusStates(t, heights...)))
+		overflowing, err := NewConsensusState(math.MaxUint64, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+		require.NoError(t, err)
+		setConsensusState(clientStore, cdc, overflowing, clienttypes.NewHeight(1, 6))
+		SetIterationKey(clientStore, clienttypes.NewHeight(1, 6))
+		before := snapshotStore(clientStore)
+
+		_, err = MigrateConsensusStates(clientStore, cdc, ConsensusStateVersionLegacy)
+		require.ErrorIs(t, err, clienttypes.ErrInvalidConsensus)
+		require.Equal(t, before, snapshotStore(clientStore))
+	})
+}
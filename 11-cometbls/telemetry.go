@@ -0,0 +1,40 @@
+package cometbls
+
+import (
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"github.com/hashicorp/go-metrics"
+)
+
+const (
+	LabelChainID = "chain_id"
+	LabelOutcome = "outcome"
+	LabelKind    = "kind"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	// KindSelf labels the verification of clients of this chain, on behalf of counterparty chains.
+	KindSelf = "self"
+	// KindCounterparty labels the verification of counterparty chain headers by this client.
+	KindCounterparty = "counterparty"
+)
+
+// emitTelemetry records the latency since start and increments the counter, labeled
+// by kind, chain ID and outcome, of the given method.
+func emitTelemetry(method, kind, chainID string, start time.Time, err error) {
+	keys := []string{"ibc", ModuleName, method}
+
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailure
+	}
+
+	telemetry.MeasureSince(start, keys...)
+	telemetry.IncrCounterWithLabels(keys, 1, []metrics.Label{
+		telemetry.NewLabel(LabelKind, kind),
+		telemetry.NewLabel(LabelChainID, chainID),
+		telemetry.NewLabel(LabelOutcome, outcome),
+	})
+}
@@ -0,0 +1,97 @@
+package cometbls
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	"github.com/hashicorp/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMetricsSink enables telemetry and captures all emitted metrics in the returned in-memory sink.
+func newTestMetricsSink(t *testing.T) *metrics.InmemSink {
+	t.Helper()
+
+	_, err := telemetry.New(telemetry.Config{Enabled: true, ServiceName: "test"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := telemetry.New(telemetry.Config{})
+		require.NoError(t, err)
+	})
+
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("test")
+	cfg.EnableHostname = false
+	_, err = metrics.NewGlobal(cfg, sink)
+	require.NoError(t, err)
+
+	return sink
+}
+
+func requireCounter(t *testing.T, sink *metrics.InmemSink, method, outcome string, expCount int) {
+	t.Helper()
+
+	requireCounterWithKind(t, sink, method, KindSelf, testChainID, outcome, expCount)
+}
+
+// requireCounterWithKind is like requireCounter for the counter of the given kind and chain ID.
+func requireCounterWithKind(t *testing.T, sink *metrics.InmemSink, method, kind, chainID, outcome string, expCount int) {
+	t.Helper()
+
+	var count int
+	for _, interval := range sink.Data() {
+		for name, counter := range interval.Counters {
+			if strings.HasPrefix(name, "test.ibc."+ModuleName+"."+method+";") &&
+				strings.Contains(name, LabelKind+"="+kind) &&
+				strings.Contains(name, LabelChainID+"="+chainID) &&
+				strings.Contains(name, LabelOutcome+"="+outcome) {
+				count += counter.Count
+			}
+		}
+	}
+	require.Equal(t, expCount, count, "%s %s %s counter", kind, method, outcome)
+}
+
+func TestConsensusHostTelemetry(t *testing.T) {
+	sink := newTestMetricsSink(t)
+	ctx := newTestContext(testChainID, 10)
+
+	host := NewConsensusHost(mockStakingKeeper{histInfo: newTestHistoricalInfo(5), ubdPeriod: 200})
+	_, err := host.GetSelfConsensusState(ctx, clienttypes.NewHeight(1, 5))
+	require.NoError(t, err)
+	require.NoError(t, host.ValidateSelfClient(ctx, newTestClientState()))
+
+	host = NewConsensusHost(mockStakingKeeper{histInfoErr: errors.New("keeper failure"), ubdPeriod: 100})
+	_, err = host.GetSelfConsensusState(ctx, clienttypes.NewHeight(1, 5))
+	require.Error(t, err)
+	require.Error(t, host.ValidateSelfClient(ctx, newTestClientState()))
+
+	requireCounter(t, sink, "get_self_consensus_state", OutcomeSuccess, 1)
+	requireCounter(t, sink, "get_self_consensus_state", OutcomeFailure, 1)
+	requireCounter(t, sink, "validate_self_client", OutcomeSuccess, 1)
+	requireCounter(t, sink, "validate_self_client", OutcomeFailure, 1)
+}
+
+func TestTelemetryKind(t *testing.T) {
+	sink := newTestMetricsSink(t)
+
+	host := NewConsensusHost(mockStakingKeeper{ubdPeriod: 200})
+	require.NoError(t, host.ValidateSelfClient(newTestContext(testChainID, 10), newTestClientState()))
+
+	ctx := newTestContext("union-devnet-1337", 10)
+	clientState, consensusState, header := newTestVerifiableHeader(t)
+	require.NoError(t, VerifyHeader(ctx, clientState, consensusState, header))
+
+	header.ZeroKnowledgeProof = nil
+	require.Error(t, VerifyHeader(ctx, clientState, consensusState, header))
+
+	requireCounterWithKind(t, sink, "validate_self_client", KindSelf, testChainID, OutcomeSuccess, 1)
+	requireCounterWithKind(t, sink, "validate_self_client", KindCounterparty, testChainID, OutcomeSuccess, 0)
+	requireCounterWithKind(t, sink, "verify_header", KindCounterparty, clientState.ChainId, OutcomeSuccess, 1)
+	requireCounterWithKind(t, sink, "verify_header", KindCounterparty, clientState.ChainId, OutcomeFailure, 1)
+	requireCounterWithKind(t, sink, "verify_header", KindSelf, clientState.ChainId, OutcomeSuccess, 0)
+}
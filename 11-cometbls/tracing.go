@@ -0,0 +1,69 @@
+package cometbls
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	SpanVerifyHeader             = "cometbls.verify_header"
+	SpanVerifyZKP                = "cometbls.verify_zkp"
+	SpanVerifyAggregateSignature = "cometbls.verify_aggregate_signature"
+
+	AttributeHeight        = "height"
+	AttributeTrustedHeight = "trusted_height"
+	AttributeProofSize     = "proof_size"
+	AttributeSigners       = "signers"
+)
+
+// Tracer starts the spans recorded around the most expensive parts of the client verification. It is meant to be
+// implemented by a thin adapter over an OpenTelemetry tracer. The context returned by Start must be derived from
+// the given one, child spans being started from it.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced operation started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value int64)
+	RecordError(err error)
+	End()
+}
+
+type tracerKey struct{}
+
+// WithTracer returns a copy of the context carrying the Tracer used to record the client verification spans.
+// Spans are not recorded unless a tracer is attached, a nil tracer disables tracing.
+func WithTracer(ctx sdk.Context, t Tracer) sdk.Context {
+	return ctx.WithValue(tracerKey{}, t)
+}
+
+// getTracer returns the Tracer attached to the context, or a tracer recording nothing if there is none.
+func getTracer(ctx context.Context) Tracer {
+	t, ok := ctx.Value(tracerKey{}).(Tracer)
+	if !ok || t == nil {
+		return noopTracer{}
+	}
+	return t
+}
+
+// endSpan records the error, if any, on the span before ending it.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, int64) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
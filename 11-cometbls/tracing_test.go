@@ -0,0 +1,106 @@
+package cometbls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordedSpan struct {
+	name       string
+	attributes map[string]int64
+	err        error
+	ended      bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value int64) { s.attributes[key] = value }
+func (s *recordedSpan) RecordError(err error)                { s.err = err }
+func (s *recordedSpan) End()                                 { s.ended = true }
+
+// recordingTracer records every span it starts, in order, along with the span each was started from.
+type recordingTracer struct {
+	spans   []*recordedSpan
+	parents []*recordedSpan
+}
+
+type recordedSpanKey struct{}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{name: name, attributes: map[string]int64{}}
+	parent, _ := ctx.Value(recordedSpanKey{}).(*recordedSpan)
+	r.spans = append(r.spans, span)
+	r.parents = append(r.parents, parent)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func TestTracingAggregateSignature(t *testing.T) {
+	message := []byte("cometbls")
+	pubkeys, aggSig := newTestAggregateSignature(t, 3, message)
+	_, twoSignersSig := newTestAggregateSignature(t, 2, message)
+
+	recorder := &recordingTracer{}
+	ctx := WithTracer(newTestContext(testChainID, 10), recorder)
+	require.NoError(t, VerifyAggregateSignature(ctx, pubkeys, message, aggSig))
+
+	require.Len(t, recorder.spans, 1)
+	require.Equal(t, SpanVerifyAggregateSignature, recorder.spans[0].name)
+	require.Equal(t, map[string]int64{AttributeSigners: 3}, recorder.spans[0].attributes)
+	require.NoError(t, recorder.spans[0].err)
+	require.True(t, recorder.spans[0].ended)
+
+	// a failed verification records the error on the span
+	recorder.spans = nil
+	err := VerifyAggregateSignature(ctx, pubkeys, message, twoSignersSig)
+	require.ErrorIs(t, err, ErrSignatureVerification)
+	require.Len(t, recorder.spans, 1)
+	require.Equal(t, map[string]int64{AttributeSigners: 3}, recorder.spans[0].attributes)
+	require.ErrorIs(t, recorder.spans[0].err, ErrSignatureVerification)
+	require.True(t, recorder.spans[0].ended)
+}
+
+func TestTracingVerifyHeader(t *testing.T) {
+	clientState, consensusState, header := newTestVerifiableHeader(t)
+
+	recorder := &recordingTracer{}
+	ctx := WithTracer(newTestContext("union-devnet-1337", 10), recorder)
+	require.NoError(t, VerifyHeader(ctx, clientState, consensusState, header))
+
+	require.Len(t, recorder.spans, 2)
+	headerSpan, zkpSpan := recorder.spans[0], recorder.spans[1]
+
+	require.Equal(t, SpanVerifyHeader, headerSpan.name)
+	require.Equal(t, map[string]int64{
+		AttributeHeight:        header.SignedHeader.Height,
+		AttributeTrustedHeight: int64(header.TrustedHeight.RevisionHeight),
+	}, headerSpan.attributes)
+	require.Nil(t, recorder.parents[0])
+	require.NoError(t, headerSpan.err)
+	require.True(t, headerSpan.ended)
+
+	// the proof verification is a child of the header verification
+	require.Equal(t, SpanVerifyZKP, zkpSpan.name)
+	require.Equal(t, map[string]int64{AttributeProofSize: int64(len(header.ZeroKnowledgeProof))}, zkpSpan.attributes)
+	require.Same(t, headerSpan, recorder.parents[1])
+	require.NoError(t, zkpSpan.err)
+	require.True(t, zkpSpan.ended)
+
+	// a failed proof verification records the error on both spans
+	recorder.spans, recorder.parents = nil, nil
+	header.SignedHeader.AppHash = testAppHash
+	err := VerifyHeader(ctx, clientState, consensusState, header)
+	require.Error(t, err)
+	require.Len(t, recorder.spans, 2)
+	require.Error(t, recorder.spans[0].err)
+	require.Error(t, recorder.spans[1].err)
+}
+
+func TestTracingDisabledByDefault(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	require.Equal(t, noopTracer{}, getTracer(ctx))
+	require.Equal(t, noopTracer{}, getTracer(WithTracer(ctx, nil)))
+	require.Equal(t, noopTracer{}, getTracer(ctx.Context()))
+
+	recorder := &recordingTracer{}
+	require.Same(t, recorder, getTracer(WithTracer(ctx, recorder).Context()))
+}
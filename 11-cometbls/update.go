@@ -2,42 +2,117 @@ package cometbls
 
 import (
 	"bytes"
+	"context"
+	"time"
 
 	errorsmod "cosmossdk.io/errors"
 	storetypes "cosmossdk.io/store/types"
 
 	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/telemetry"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
-	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
-	host "github.com/cosmos/ibc-go/v8/modules/core/24-host"
 	"github.com/cosmos/ibc-go/v8/modules/core/exported"
 )
 
 // VerifyClientMessage checks if the clientMessage is of type Header or Misbehaviour and verifies the message
+// Errors are scoped with the client ID attached to the context, see WithClientID.
 func (cs *ClientState) VerifyClientMessage(
 	ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore,
 	clientMsg exported.ClientMessage,
-) error {
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = errorsmod.Wrapf(err, "client %s", GetClientID(ctx))
+		}
+	}()
+
+	if !cs.FrozenHeight.IsZero() {
+		return clienttypes.ErrClientFrozen
+	}
+
+	if err := checkClientMessage(clientMsg); err != nil {
+		return err
+	}
+
 	switch msg := clientMsg.(type) {
 	case *Header:
+		if err := cs.checkMonotonicHeight(clientStore, cdc, msg); err != nil {
+			return err
+		}
 		return cs.verifyHeader(ctx, clientStore, cdc, msg)
 	case *Misbehaviour:
+		if err := msg.ValidateBasic(); err != nil {
+			return err
+		}
 		return cs.verifyMisbehaviour(ctx, clientStore, cdc, msg)
 	default:
 		return clienttypes.ErrInvalidClientType
 	}
 }
 
-// verifyHeader returns an error if:
-// - the client or header provided are not parseable to tendermint types
-// - the header is invalid
-// - header height is less than or equal to the trusted header height
-// - header revision is not equal to trusted header revision
-// - header valset commit verification fails
-// - header timestamp is past the trusting period in relation to the consensus state
-// - header timestamp is less than or equal to the consensus state timestamp
+// checkClientMessage returns an ErrInvalidClientType if the client message is nil or neither a Header nor a
+// Misbehaviour, and an ErrInvalidHeader if a header is missing its signed header or trusted height, so that
+// malformed client messages are rejected instead of being dereferenced.
+func checkClientMessage(clientMsg exported.ClientMessage) error {
+	switch msg := clientMsg.(type) {
+	case *Header:
+		if msg == nil {
+			return errorsmod.Wrap(clienttypes.ErrInvalidClientType, "client message cannot be a nil header")
+		}
+		return checkHeaderNotNil(msg)
+	case *Misbehaviour:
+		if msg == nil {
+			return errorsmod.Wrap(clienttypes.ErrInvalidClientType, "client message cannot be a nil misbehaviour")
+		}
+		if msg.Header_1 == nil || msg.Header_2 == nil {
+			return errorsmod.Wrap(ErrInvalidHeader, "misbehaviour headers cannot be nil")
+		}
+		if err := checkHeaderNotNil(msg.Header_1); err != nil {
+			return errorsmod.Wrap(err, "misbehaviour Header_1")
+		}
+		if err := checkHeaderNotNil(msg.Header_2); err != nil {
+			return errorsmod.Wrap(err, "misbehaviour Header_2")
+		}
+		return nil
+	case nil:
+		return errorsmod.Wrap(clienttypes.ErrInvalidClientType, "client message cannot be nil")
+	default:
+		return errorsmod.Wrapf(clienttypes.ErrInvalidClientType, "expected %T or %T, got %T", &Header{}, &Misbehaviour{}, clientMsg)
+	}
+}
+
+func checkHeaderNotNil(header *Header) error {
+	if header.SignedHeader == nil {
+		return errorsmod.Wrap(ErrInvalidHeader, "signed header cannot be nil")
+	}
+	if header.TrustedHeight == nil {
+		return errorsmod.Wrap(ErrInvalidHeader, "trusted height cannot be nil")
+	}
+	return nil
+}
+
+// checkMonotonicHeight returns an ErrNonMonotonicHeight if the header is not higher than the client latest height,
+// so that ancient headers cannot be replayed to fill in past heights. A header at a height a consensus state is
+// already stored at is let through: a matching one is a duplicate update UpdateState performs no-op on and a
+// conflicting one is double-sign evidence CheckForMisbehaviour freezes the client on.
+// Misbehaviour headers are not subject to this check as evidence may be at any height.
+func (cs *ClientState) checkMonotonicHeight(clientStore storetypes.KVStore, cdc codec.BinaryCodec, header *Header) error {
+	if header.GetHeight().GT(cs.GetLatestHeight()) {
+		return nil
+	}
+
+	if _, found := GetConsensusState(clientStore, cdc, header.GetHeight()); found {
+		return nil
+	}
+
+	return errorsmod.Wrapf(ErrNonMonotonicHeight, "header height %s must be greater than the client latest height %s",
+		header.GetHeight(), cs.GetLatestHeight())
+}
+
+// verifyHeader returns an error if the trusted consensus state of the header cannot be found in the client store,
+// or if the header does not pass VerifyHeader against it.
 func (cs *ClientState) verifyHeader(
 	ctx sdk.Context, clientStore storetypes.KVStore, cdc codec.BinaryCodec,
 	header *Header,
@@ -45,7 +120,48 @@ func (cs *ClientState) verifyHeader(
 	// Retrieve trusted consensus states for each Header in misbehaviour
 	consState, found := GetConsensusState(clientStore, cdc, header.TrustedHeight)
 	if !found {
-		return errorsmod.Wrapf(clienttypes.ErrConsensusStateNotFound, "could not get trusted consensus state from clientStore for Header at TrustedHeight: %s", header.TrustedHeight)
+		return errorsmod.Wrapf(ErrConsensusStateNotFound, "could not get trusted consensus state from clientStore for Header at TrustedHeight: %s", header.TrustedHeight)
+	}
+
+	return VerifyHeader(ctx, cs, consState, header)
+}
+
+// VerifyHeader verifies the header against the trusted consensus state without accessing the client store.
+// It returns an error if:
+// - header is not structurally valid, see Header.ValidateBasic
+// - client chain-id is longer than the zero knowledge proof can commit to
+// - header revision is not equal to trusted header revision
+// - header revision is not equal to the revision of the client chain-id
+// - header timestamp is less than the trusted consensus state timestamp
+// - header timestamp is past the trusting period in relation to the trusted consensus state
+// - header height is less than or equal to the trusted header height
+// - header timestamp is past the max clock drift in relation to the block time
+// - header validators hash does not match the trusted next validators hash for an adjacent header
+// - the zero knowledge proof, attesting that enough of the trusted validators signed the header, is invalid
+func VerifyHeader(ctx sdk.Context, cs *ClientState, consState *ConsensusState, header *Header) (err error) {
+	defer func(start time.Time) {
+		emitTelemetry("verify_header", KindCounterparty, cs.ChainId, start, err)
+	}(telemetry.Now())
+
+	spanCtx, span := getTracer(ctx).Start(ctx.Context(), SpanVerifyHeader)
+	defer func() { endSpan(span, err) }()
+
+	// structurally invalid headers are rejected before any proof verification
+	if err := header.ValidateBasic(); err != nil {
+		return err
+	}
+	span.SetAttribute(AttributeHeight, header.SignedHeader.Height)
+	span.SetAttribute(AttributeTrustedHeight, int64(header.TrustedHeight.RevisionHeight))
+
+	// headers do not carry a chain-id, the proof public inputs commit to the client chain-id instead and a
+	// header of another chain fails the proof verification. Chain-ids the circuit cannot commit to are rejected
+	// before any verification.
+	if len(cs.ChainId) > MaxProverChainIDLen {
+		return errorsmod.Wrapf(
+			ErrInvalidChainID,
+			"client chain-id %s is %d bytes long, headers can only be proven for chain-ids of at most %d bytes",
+			cs.ChainId, len(cs.ChainId), MaxProverChainIDLen,
+		)
 	}
 
 	// UpdateClient only accepts updates with a header at the same revision
@@ -58,6 +174,19 @@ func (cs *ClientState) verifyHeader(
 		)
 	}
 
+	// the revision number is the only part of the chain-id a header can be traced back to, a header at another
+	// revision than the one of the client chain-id is a header of another chain. Chain-ids without a revision
+	// number are left to the proof verification.
+	if clienttypes.IsRevisionFormat(cs.ChainId) {
+		if revision, err := parseChainIDRevision(cs.ChainId); err == nil && revision != header.TrustedHeight.RevisionNumber {
+			return errorsmod.Wrapf(
+				ErrInvalidChainID,
+				"header revision %d does not match the revision %d of client chain-id %s",
+				header.TrustedHeight.RevisionNumber, revision, cs.ChainId,
+			)
+		}
+	}
+
 	if consState.GetTimestamp() > uint64(header.SignedHeader.GetTime().UnixNano()) {
 		return errorsmod.Wrapf(
 			ErrInvalidHeaderTimestamp,
@@ -66,6 +195,15 @@ func (cs *ClientState) verifyHeader(
 		)
 	}
 
+	// the trusted consensus state may be at any height, as long as the header is within its trusting period
+	if cs.IsExpired(consState.GetTime(), header.GetTime()) {
+		return errorsmod.Wrapf(
+			ErrTrustingPeriodExpired,
+			"trusted consensus state at height %s expired before the header time (%s + %s <= %s)",
+			header.TrustedHeight, consState.GetTime(), cs.GetTrustingDuration(), header.GetTime(),
+		)
+	}
+
 	// assert header height is newer than consensus state
 	if header.GetHeight().LTE(*header.TrustedHeight) {
 		return errorsmod.Wrapf(
@@ -74,11 +212,8 @@ func (cs *ClientState) verifyHeader(
 		)
 	}
 
-	if header.GetTime().UnixNano() >= ctx.BlockHeader().Time.UnixNano()+int64(cs.MaxClockDrift) {
-		return errorsmod.Wrapf(
-			clienttypes.ErrInvalidHeader,
-			"header time >= max drift (%d >= currentTime + %d)", header.GetTime().UnixNano(), cs.MaxClockDrift,
-		)
+	if err := cs.CheckClockDrift(ctx, header.GetTime(), nil); err != nil {
+		return err
 	}
 
 	if header.SignedHeader.Height == int64(header.TrustedHeight.RevisionHeight)+1 &&
@@ -89,6 +224,16 @@ func (cs *ClientState) verifyHeader(
 		)
 	}
 
+	return verifyZKP(spanCtx, cs, consState, header)
+}
+
+// verifyZKP verifies the zero knowledge proof of the header, attesting that enough of the trusted next validators
+// signed it, traced as a child span of the context.
+func verifyZKP(ctx context.Context, cs *ClientState, consState *ConsensusState, header *Header) (err error) {
+	_, span := getTracer(ctx).Start(ctx, SpanVerifyZKP)
+	span.SetAttribute(AttributeProofSize, int64(len(header.ZeroKnowledgeProof)))
+	defer func() { endSpan(span, err) }()
+
 	zkp, err := ParseZKP(header.ZeroKnowledgeProof)
 
 	if err != nil {
@@ -105,20 +250,23 @@ func (cs *ClientState) verifyHeader(
 	})
 }
 
-// UpdateState may be used to either create a consensus state for:
-// - a future height greater than the latest client state height
-// - a past height that was skipped during bisection
-// If we are updating to a past height, a consensus state is created for that height to be persisted in client store
-// If we are updating to a future height, the consensus state is created and the client state is updated to reflect
-// the new latest height
+// UpdateState creates a consensus state for a future height greater than the latest client state height and
+// updates the client state to reflect the new latest height. Past heights skipped during bisection cannot be
+// filled in, as VerifyClientMessage rejects headers not higher than the latest height.
 // A list containing the updated consensus height is returned.
 // UpdateState must only be used to update within a single revision, thus header revision number and trusted height's revision
 // number must be the same. To update to a new revision, use a separate upgrade path
 // UpdateState will prune the oldest consensus state if it is expired.
-// If the provided clientMsg is not of type of Header then the handler will noop and empty slice is returned.
+// If a consensus state already exists at the header height, the update is a no-op when it matches the header and
+// the client is frozen when it conflicts with it. The client is also frozen if the header is not newer than the
+// consensus state at the preceding height.
+// The persisted states are those CheckHeaderAndUpdateStateDryRun returns, the header having already been verified
+// with VerifyClientMessage.
+// If the provided clientMsg is not of type of Header, or is a nil or malformed Header, then the handler will noop and
+// empty slice is returned.
 func (cs ClientState) UpdateState(ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore, clientMsg exported.ClientMessage) []exported.Height {
 	header, ok := clientMsg.(*Header)
-	if !ok {
+	if !ok || checkClientMessage(header) != nil {
 		// clientMsg is invalid Misbehaviour, no update necessary
 		return []exported.Height{}
 	}
@@ -129,29 +277,121 @@ func (cs ClientState) UpdateState(ctx sdk.Context, cdc codec.BinaryCodec, client
 		cs.pruneOldestConsensusState(ctx, cdc, clientStore)
 	}
 
-	// check for duplicate update
-	if _, found := GetConsensusState(clientStore, cdc, header.GetHeight()); found {
-		// perform no-op
-		return []exported.Height{header.GetHeight()}
+	clientState, consensusState, isNew, err := cs.checkHeaderAndUpdateState(cdc, clientStore, header)
+	// the sentinel frozen height is below the latest height of any valid client, this error should never occur
+	if err != nil {
+		panic(err)
 	}
 
-	height := header.GetHeight().(clienttypes.Height)
-	if height.GT(cs.LatestHeight) {
-		cs.LatestHeight = height
+	// the header is evidence of misbehaviour, only the frozen client state is persisted
+	if !clientState.FrozenHeight.IsZero() {
+		setClientState(clientStore, cdc, clientState)
+		return []exported.Height{}
 	}
 
-	consensusState := &ConsensusState{
-		Timestamp:          uint64(header.GetTime().UnixNano()),
-		Root:               commitmenttypes.NewMerkleRoot(header.SignedHeader.GetAppHash()),
-		NextValidatorsHash: header.SignedHeader.NextValidatorsHash,
+	// perform no-op on duplicate update
+	if !isNew {
+		return []exported.Height{header.GetHeight()}
 	}
 
 	// set client state, consensus state and associated metadata
-	setClientState(clientStore, cdc, &cs)
+	setClientState(clientStore, cdc, clientState)
 	setConsensusState(clientStore, cdc, consensusState, header.GetHeight())
 	setConsensusMetadata(ctx, clientStore, header.GetHeight())
+	incrementUpdateCount(clientStore)
 
-	return []exported.Height{height}
+	return []exported.Height{header.GetHeight()}
+}
+
+// CheckHeaderAndUpdateStateDryRun verifies the header and returns the client state and consensus state an update
+// with it would result in, without writing them to the client store. It allows relayers to simulate an update
+// before broadcasting it. If a consensus state already exists at the header height, the stored consensus state is
+// returned along with the provided client state if it matches the header, or a frozen copy of it if it conflicts.
+// A frozen copy is also returned if the header is not newer than the preceding consensus state.
+func CheckHeaderAndUpdateStateDryRun(
+	ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore,
+	clientState *ClientState, header *Header,
+) (*ClientState, *ConsensusState, error) {
+	if err := clientState.VerifyClientMessage(ctx, cdc, clientStore, header); err != nil {
+		return nil, nil, err
+	}
+
+	newClientState, consensusState, _, err := clientState.checkHeaderAndUpdateState(cdc, clientStore, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newClientState, consensusState, nil
+}
+
+// checkHeaderAndUpdateState returns the client state and consensus state an update with the verified header results
+// in, along with whether the consensus state is new, without writing them to the client store. It is the single code
+// path of both UpdateState and CheckHeaderAndUpdateStateDryRun.
+// A conflicting consensus state at the same height is evidence of a fork, as is a consensus state not newer than the
+// one at the preceding height: a frozen copy of the client state is returned for both instead of overwriting them.
+func (cs ClientState) checkHeaderAndUpdateState(
+	cdc codec.BinaryCodec, clientStore storetypes.KVStore, header *Header,
+) (*ClientState, *ConsensusState, bool, error) {
+	// check for duplicate update
+	if existingConsState, found := GetConsensusState(clientStore, cdc, header.GetHeight()); found {
+		if !existingConsState.Equal(header.ConsensusState()) {
+			frozenClientState, err := cs.frozenCopy()
+			return frozenClientState, existingConsState, false, err
+		}
+
+		return &cs, existingConsState, false, nil
+	}
+
+	clientState, consensusState := cs.applyHeader(header)
+
+	// consensus state timestamps must strictly increase with heights
+	if !isTimeMonotonic(clientStore, cdc, header.GetHeight(), consensusState) {
+		frozenClientState, err := cs.frozenCopy()
+		return frozenClientState, consensusState, false, err
+	}
+
+	return clientState, consensusState, true, nil
+}
+
+// frozenCopy returns a copy of the client state frozen at the sentinel FrozenHeight.
+func (cs ClientState) frozenCopy() (*ClientState, error) {
+	frozenClientState := cs.Copy()
+	if err := frozenClientState.Freeze(FrozenHeight); err != nil {
+		return nil, err
+	}
+
+	return frozenClientState, nil
+}
+
+// isTimeMonotonic returns false if the consensus state stored at the highest height lower than the given one
+// is not older than the consensus state, or if the consensus state stored at the lowest height greater than
+// the given one is not newer than it. Missing neighbours are not checked.
+func isTimeMonotonic(clientStore storetypes.KVStore, cdc codec.BinaryCodec, height exported.Height, consensusState *ConsensusState) bool {
+	if prevConsState, found := GetPreviousConsensusState(clientStore, cdc, height); found {
+		if prevConsState.GetTimestampNanos() >= consensusState.GetTimestampNanos() {
+			return false
+		}
+	}
+
+	if nextConsState, found := GetNextConsensusState(clientStore, cdc, height); found {
+		if nextConsState.GetTimestampNanos() <= consensusState.GetTimestampNanos() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyHeader returns the client state and consensus state resulting from an update with the header.
+// The latest height is only bumped if the header is newer so that the client cannot be moved backwards.
+// The header is expected to have been verified beforehand.
+func (cs ClientState) applyHeader(header *Header) (*ClientState, *ConsensusState) {
+	height := header.GetHeight().(clienttypes.Height)
+	if height.GT(cs.GetLatestHeight()) {
+		cs.LatestHeight = height
+	}
+
+	return &cs, header.ConsensusState()
 }
 
 // pruneOldestConsensusState will retrieve the earliest consensus state for this clientID and check if it is expired. If it is,
@@ -168,10 +408,10 @@ func (cs ClientState) pruneOldestConsensusState(ctx sdk.Context, cdc codec.Binar
 		consState, found := GetConsensusState(clientStore, cdc, height)
 		// this error should never occur
 		if !found {
-			panic(errorsmod.Wrapf(clienttypes.ErrConsensusStateNotFound, "failed to retrieve consensus state at height: %s", height))
+			panic(errorsmod.Wrapf(ErrConsensusStateNotFound, "failed to retrieve consensus state at height: %s", height))
 		}
 
-		if cs.IsExpired(consState.Timestamp, uint64(ctx.BlockTime().UnixNano())) {
+		if cs.IsExpired(consState.GetTime(), ctx.BlockTime()) {
 			pruneHeight = height
 		}
 
@@ -190,7 +430,24 @@ func (cs ClientState) pruneOldestConsensusState(ctx sdk.Context, cdc codec.Binar
 // UpdateStateOnMisbehaviour updates state upon misbehaviour, freezing the ClientState. This method should only be called when misbehaviour is detected
 // as it does not perform any misbehaviour checks.
 func (cs ClientState) UpdateStateOnMisbehaviour(ctx sdk.Context, cdc codec.BinaryCodec, clientStore storetypes.KVStore, _ exported.ClientMessage) {
-	cs.FrozenHeight = FrozenHeight
+	// the sentinel frozen height is below the latest height of any valid client, this error should never occur
+	if err := cs.Freeze(FrozenHeight); err != nil {
+		panic(err)
+	}
+
+	setClientState(clientStore, cdc, &cs)
+}
+
+// Freeze freezes the client at the given frozen height, which must be a non-zero height at or below the client
+// latest height. Clients are frozen at the FrozenHeight sentinel on misbehaviour.
+func (cs *ClientState) Freeze(frozenHeight clienttypes.Height) error {
+	if frozenHeight.IsZero() {
+		return errorsmod.Wrap(clienttypes.ErrInvalidHeight, "frozen height cannot be zero")
+	}
+	if frozenHeight.GT(cs.GetLatestHeight()) {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidHeight, "frozen height %s cannot be greater than the latest height %s", frozenHeight, cs.GetLatestHeight())
+	}
 
-	clientStore.Set(host.ClientStateKey(), clienttypes.MustMarshalClientState(cdc, &cs))
+	cs.FrozenHeight = frozenHeight
+	return nil
 }
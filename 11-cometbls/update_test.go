@@ -0,0 +1,648 @@
+package cometbls
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmossdk.io/store/dbadapter"
+	storetypes "cosmossdk.io/store/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	"github.com/cosmos/ibc-go/v8/modules/core/exported"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCodec() codec.BinaryCodec {
+	registry := codectypes.NewInterfaceRegistry()
+	clienttypes.RegisterInterfaces(registry)
+	RegisterInterfaces(registry)
+	return codec.NewProtoCodec(registry)
+}
+
+func newTestClientStore() storetypes.KVStore {
+	return dbadapter.Store{DB: dbm.NewMemDB()}
+}
+
+func getTestClientState(t *testing.T, clientStore storetypes.KVStore, cdc codec.BinaryCodec) *ClientState {
+	t.Helper()
+
+	clientState, found := getClientState(clientStore, cdc)
+	require.True(t, found)
+	return clientState
+}
+
+// newTestVerifiableHeader returns a header carrying the zero knowledge proof of the verifier test vector, along
+// with a client state and trusted consensus state it can be verified against.
+func newTestVerifiableHeader(t *testing.T) (*ClientState, *ConsensusState, *Header) {
+	t.Helper()
+
+	zkp, err := hex.DecodeString("294A48A750D5C2CF926516752FF484EEBE55FF26CF8A8A7536D98794CF062DB6214D0C9E5C6B164111927A1630889619DBBB40149D8E2D32898E7ACB765542CD0EB8A8E04CCC254C3BFDC2FCE627D59C3C05E2AC76E03977855DD889C1C9BA432FF7FF4DEFCB5286555D36D22DD073A859140508AF9B977F38EB9A604E99A5F6109D43A4AFA0AB161DA2B261DED80FBC0C36E57DE2001338941C834E3262CF751BC1BFC6EC27BB8E106BAAB976285BAC1D4AC38D1B759C8A2852D65CE239974F1275CC6765B3D174FD1122EFDE86137D19F07483FEF5244B1D74B2D9DC598AC32A5CA10E8837FBC89703F4D0D46912CF4AF82341C30C2A1F3941849CC011A56E18AD2162EEB71289B8821CC01875BC1E35E5FC1EBD9114C0B2C0F0D9A96C394001468C70A1716CA98EBE82B1E614D4D9B07292EBAD5B60E0C76FD1D58B485E7D1FB1E07F51A0C68E4CA59A399FCF0634D9585BE478E37480423681B984E96C0A1698D8FCB1DF51CAE023B045E114EED9CB233A5742D9E60E1097206EB20A5058")
+	require.NoError(t, err)
+	valHash, err := hex.DecodeString("1B7EA0F1B3E574F8D50A12827CCEA43CFF858C2716AE05370CC40AE8EC521FD8")
+	require.NoError(t, err)
+	appHash, err := hex.DecodeString("3A34FC963EEFAAE9B7C0D3DFF89180D91F3E31073E654F732340CEEDD77DD25B")
+	require.NoError(t, err)
+
+	headerTime := time.Unix(1710783278, 499600406)
+	trustedHeight := clienttypes.NewHeight(1337, 3405691500)
+	clientState := NewClientState("union-devnet-1337", uint64(2*time.Hour), uint64(3*time.Hour), uint64(time.Minute), trustedHeight)
+	consensusState, err := NewConsensusState(uint64(headerTime.Add(-time.Hour).UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), valHash)
+	require.NoError(t, err)
+
+	header := &Header{
+		SignedHeader: &LightHeader{
+			Height:             3405691582,
+			Time:               headerTime,
+			ValidatorsHash:     valHash,
+			NextValidatorsHash: valHash,
+			AppHash:            appHash,
+		},
+		TrustedHeight:      &trustedHeight,
+		ZeroKnowledgeProof: zkp,
+	}
+
+	return clientState, consensusState, header
+}
+
+// snapshotStore returns a copy of every key/value pair of the store.
+func snapshotStore(store storetypes.KVStore) map[string][]byte {
+	snapshot := make(map[string][]byte)
+	iterator := store.Iterator(nil, nil)
+	defer iterator.Close()
+	for ; iterator.Valid(); iterator.Next() {
+		snapshot[string(iterator.Key())] = iterator.Value()
+	}
+	return snapshot
+}
+
+func TestCheckHeaderAndUpdateStateDryRun(t *testing.T) {
+	ctx := newTestContext("union-devnet-1337", 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	clientState, consensusState, header := newTestVerifiableHeader(t)
+	require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+	snapshot := snapshotStore(clientStore)
+
+	newClientState, newConsensusState, err := CheckHeaderAndUpdateStateDryRun(ctx, cdc, clientStore, clientState, header)
+	require.NoError(t, err)
+	require.Equal(t, header.GetHeight(), newClientState.LatestHeight)
+	require.True(t, header.ConsensusState().Equal(newConsensusState))
+	require.Equal(t, snapshot, snapshotStore(clientStore))
+	require.True(t, clientState.Equal(getTestClientState(t, clientStore, cdc)))
+
+	// an invalid header is rejected without touching the store either
+	header.SignedHeader.AppHash = testAppHash
+	_, _, err = CheckHeaderAndUpdateStateDryRun(ctx, cdc, clientStore, clientState, header)
+	require.Error(t, err)
+	require.Equal(t, snapshot, snapshotStore(clientStore))
+	header.SignedHeader.AppHash = newConsensusState.Root.Hash
+
+	// the real update persists the states returned by the dry run
+	clientState.UpdateState(ctx, cdc, clientStore, header)
+	require.True(t, newClientState.Equal(getTestClientState(t, clientStore, cdc)))
+	storedConsensusState, found := GetConsensusState(clientStore, cdc, header.GetHeight())
+	require.True(t, found)
+	require.True(t, newConsensusState.Equal(storedConsensusState))
+
+	// a conflicting consensus state at the header height would freeze the client
+	clientStore = newTestClientStore()
+	require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+	conflictingConsensusState := *storedConsensusState
+	conflictingConsensusState.Timestamp++
+	setConsensusState(clientStore, cdc, &conflictingConsensusState, header.GetHeight())
+	snapshot = snapshotStore(clientStore)
+
+	frozenClientState, existingConsensusState, err := CheckHeaderAndUpdateStateDryRun(ctx, cdc, clientStore, clientState, header)
+	require.NoError(t, err)
+	require.Equal(t, FrozenHeight, frozenClientState.FrozenHeight)
+	require.True(t, conflictingConsensusState.Equal(existingConsensusState))
+	require.True(t, clientState.FrozenHeight.IsZero())
+	require.Equal(t, snapshot, snapshotStore(clientStore))
+}
+
+func TestFreeze(t *testing.T) {
+	testCases := []struct {
+		name         string
+		frozenHeight clienttypes.Height
+		expErr       error
+	}{
+		{"sentinel frozen height", FrozenHeight, nil},
+		{"frozen at the latest height", clienttypes.NewHeight(1, 5), nil},
+		{"frozen above the latest height", clienttypes.NewHeight(1, 6), clienttypes.ErrInvalidHeight},
+		{"frozen in a later revision", clienttypes.NewHeight(2, 1), clienttypes.ErrInvalidHeight},
+		{"zero frozen height", clienttypes.ZeroHeight(), clienttypes.ErrInvalidHeight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState := newTestClientState()
+
+			err := clientState.Freeze(tc.frozenHeight)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.frozenHeight, clientState.FrozenHeight)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+				require.True(t, clientState.FrozenHeight.IsZero())
+			}
+		})
+	}
+}
+
+func TestUpdateStateOnMisbehaviour(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	clientState := newTestClientState()
+	consensusState, err := NewConsensusState(1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+	require.NoError(t, err)
+	require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+	clientState.UpdateStateOnMisbehaviour(ctx, cdc, clientStore, nil)
+
+	frozenClientState := getTestClientState(t, clientStore, cdc)
+	require.Equal(t, FrozenHeight, frozenClientState.FrozenHeight)
+
+	err = frozenClientState.VerifyMembership(
+		ctx, clientStore, cdc, clientState.LatestHeight, 0, 0, nil, commitmenttypes.NewMerklePath("ibc"), nil,
+	)
+	require.ErrorIs(t, err, clienttypes.ErrClientFrozen)
+
+	err = frozenClientState.VerifyNonMembership(
+		ctx, clientStore, cdc, clientState.LatestHeight, 0, 0, nil, commitmenttypes.NewMerklePath("ibc"),
+	)
+	require.ErrorIs(t, err, clienttypes.ErrClientFrozen)
+
+	err = frozenClientState.VerifyClientMessage(ctx, cdc, clientStore, newTestHeader(6, 5, ctx.BlockTime()))
+	require.ErrorIs(t, err, clienttypes.ErrClientFrozen)
+}
+
+func TestVerifyHeaderMaxClockDrift(t *testing.T) {
+	testCases := []struct {
+		name    string
+		drift   time.Duration
+		expPass bool
+	}{
+		{"header within max clock drift", time.Minute - time.Nanosecond, true},
+		{"header at max clock drift", time.Minute, false},
+		{"header beyond max clock drift", time.Minute + time.Nanosecond, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(testChainID, 10)
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			clientState.TrustingPeriod = uint64(time.Hour)
+			clientState.UnbondingPeriod = uint64(2 * time.Hour)
+			clientState.MaxClockDrift = uint64(time.Minute)
+			consensusState, err := NewConsensusState(uint64(ctx.BlockTime().UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+			header := newTestHeader(6, 5, ctx.BlockTime().Add(tc.drift))
+			err = clientState.VerifyClientMessage(ctx, cdc, clientStore, header)
+			// the header does not carry a valid zero knowledge proof, verification always fails
+			require.Error(t, err)
+			if tc.expPass {
+				require.NotErrorIs(t, err, ErrInvalidHeaderTimestamp)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidHeaderTimestamp)
+			}
+		})
+	}
+}
+
+func TestUpdateStateDuplicateConsensusState(t *testing.T) {
+	testCases := []struct {
+		name      string
+		malleate  func(cdc codec.BinaryCodec, clientStore storetypes.KVStore, header *Header)
+		expFrozen bool
+		expHeight clienttypes.Height
+	}{
+		{
+			"identical consensus state is a no-op",
+			func(cdc codec.BinaryCodec, clientStore storetypes.KVStore, header *Header) {
+				setConsensusState(clientStore, cdc, header.ConsensusState(), header.GetHeight())
+			},
+			false,
+			clienttypes.NewHeight(1, 5),
+		},
+		{
+			"conflicting root freezes the client",
+			func(cdc codec.BinaryCodec, clientStore storetypes.KVStore, header *Header) {
+				consensusState := header.ConsensusState()
+				consensusState.Root = commitmenttypes.NewMerkleRoot(testNextValidatorsHash)
+				setConsensusState(clientStore, cdc, consensusState, header.GetHeight())
+			},
+			true,
+			clienttypes.NewHeight(1, 5),
+		},
+		{
+			"conflicting next validators hash freezes the client",
+			func(cdc codec.BinaryCodec, clientStore storetypes.KVStore, header *Header) {
+				consensusState := header.ConsensusState()
+				consensusState.NextValidatorsHash = testAppHash
+				setConsensusState(clientStore, cdc, consensusState, header.GetHeight())
+			},
+			true,
+			clienttypes.NewHeight(1, 5),
+		},
+		{
+			"new height is written",
+			func(_ codec.BinaryCodec, _ storetypes.KVStore, _ *Header) {},
+			false,
+			clienttypes.NewHeight(1, 6),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(testChainID, 10)
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			consensusState, err := NewConsensusState(1, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+			header := newTestHeader(6, 5, ctx.BlockTime())
+			tc.malleate(cdc, clientStore, header)
+			existingConsState, _ := GetConsensusState(clientStore, cdc, header.GetHeight())
+
+			clientState.UpdateState(ctx, cdc, clientStore, header)
+
+			updatedClientState := getTestClientState(t, clientStore, cdc)
+			require.Equal(t, tc.expFrozen, !updatedClientState.FrozenHeight.IsZero())
+			require.Equal(t, tc.expHeight, updatedClientState.LatestHeight)
+
+			storedConsState, found := GetConsensusState(clientStore, cdc, header.GetHeight())
+			require.True(t, found)
+			if existingConsState != nil {
+				// an existing consensus state is never overwritten
+				require.True(t, existingConsState.Equal(storedConsState))
+			} else {
+				require.True(t, header.ConsensusState().Equal(storedConsState))
+			}
+		})
+	}
+}
+
+func TestVerifyHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(consensusState *ConsensusState, header *Header)
+		expPass  bool
+		expErr   error
+	}{
+		{
+			"valid header skipping intermediate heights",
+			func(_ *ConsensusState, _ *Header) {},
+			true,
+			nil,
+		},
+		{
+			"trusted consensus state close to expiry",
+			func(consensusState *ConsensusState, header *Header) {
+				consensusState.Timestamp = uint64(header.GetTime().Add(-2*time.Hour + time.Nanosecond).UnixNano())
+			},
+			true,
+			nil,
+		},
+		{
+			// the trust level is enforced by the circuit, a proof for a different trusted validator set
+			// does not attest that enough of the trusted voting power signed the header
+			"insufficient trusted signing power",
+			func(consensusState *ConsensusState, _ *Header) {
+				consensusState.NextValidatorsHash = testNextValidatorsHash
+			},
+			false,
+			nil,
+		},
+		{
+			"header below the trusted height",
+			func(_ *ConsensusState, header *Header) {
+				header.SignedHeader.Height = int64(header.TrustedHeight.RevisionHeight) - 1
+			},
+			false,
+			ErrInvalidHeaderHeight,
+		},
+		{
+			"header at the trusted height",
+			func(_ *ConsensusState, header *Header) {
+				header.SignedHeader.Height = int64(header.TrustedHeight.RevisionHeight)
+			},
+			false,
+			ErrInvalidHeaderHeight,
+		},
+		{
+			"trusted consensus state expired at the header time",
+			func(consensusState *ConsensusState, header *Header) {
+				consensusState.Timestamp = uint64(header.GetTime().Add(-2 * time.Hour).UnixNano())
+			},
+			false,
+			ErrTrustingPeriodExpired,
+		},
+		{
+			"header older than the trusted consensus state",
+			func(consensusState *ConsensusState, header *Header) {
+				consensusState.Timestamp = uint64(header.GetTime().UnixNano()) + 1
+			},
+			false,
+			ErrInvalidHeaderTimestamp,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext("union-devnet-1337", 10)
+			clientState, consensusState, header := newTestVerifiableHeader(t)
+			tc.malleate(consensusState, header)
+
+			err := VerifyHeader(ctx, clientState, consensusState, header)
+			if tc.expPass {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyHeaderChainID(t *testing.T) {
+	testCases := []struct {
+		name    string
+		chainID string
+		expPass bool
+		expErr  error
+	}{
+		{"matching chain-id", "union-devnet-1337", true, nil},
+		// the header revision is derived from its trusted height
+		{"header of another chain revision", "union-devnet-1338", false, ErrInvalidChainID},
+		// the proof public inputs commit to the chain-id of the header
+		{"header of another chain at the same revision", "union-testnet-1337", false, nil},
+		{"header of a chain-id without revision number", "union", false, nil},
+		{"chain-id too long to be proven", strings.Repeat("a", MaxProverChainIDLen+1), false, ErrInvalidChainID},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientState, consensusState, header := newTestVerifiableHeader(t)
+			clientState.ChainId = tc.chainID
+
+			err := VerifyHeader(newTestContext("union-devnet-1337", 10), clientState, consensusState, header)
+			if tc.expPass {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				require.ErrorContains(t, err, tc.chainID)
+			}
+		})
+	}
+}
+
+func TestVerifyClientMessageMonotonicHeight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		malleate func(clientState *ClientState, clientStore storetypes.KVStore, header *Header)
+		expErr   error
+	}{
+		{
+			"header strictly higher than the latest height",
+			func(_ *ClientState, _ storetypes.KVStore, _ *Header) {},
+			nil,
+		},
+		{
+			"header at the latest height",
+			func(clientState *ClientState, _ storetypes.KVStore, header *Header) {
+				clientState.LatestHeight = header.GetHeight().(clienttypes.Height)
+			},
+			ErrNonMonotonicHeight,
+		},
+		{
+			"header lower than the latest height",
+			func(clientState *ClientState, _ storetypes.KVStore, header *Header) {
+				clientState.LatestHeight = header.GetHeight().Increment().(clienttypes.Height)
+			},
+			ErrNonMonotonicHeight,
+		},
+		{
+			// duplicate updates are left for UpdateState to perform no-op on
+			"replayed header matching the stored consensus state",
+			func(clientState *ClientState, clientStore storetypes.KVStore, header *Header) {
+				clientState.LatestHeight = header.GetHeight().(clienttypes.Height)
+				setConsensusState(clientStore, newTestCodec(), header.ConsensusState(), header.GetHeight())
+			},
+			nil,
+		},
+		{
+			// double-sign evidence is left for CheckForMisbehaviour to freeze the client
+			"header conflicting with the stored consensus state",
+			func(clientState *ClientState, clientStore storetypes.KVStore, header *Header) {
+				clientState.LatestHeight = header.GetHeight().(clienttypes.Height)
+				conflictingConsensusState := header.ConsensusState()
+				conflictingConsensusState.Timestamp++
+				setConsensusState(clientStore, newTestCodec(), conflictingConsensusState, header.GetHeight())
+			},
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext("union-devnet-1337", 10)
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState, consensusState, header := newTestVerifiableHeader(t)
+			setConsensusState(clientStore, cdc, consensusState, header.TrustedHeight)
+			tc.malleate(clientState, clientStore, header)
+
+			err := clientState.VerifyClientMessage(ctx, cdc, clientStore, header)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestVerifyClientMessageMisbehaviourBelowLatestHeight(t *testing.T) {
+	ctx := newTestContext("union-devnet-1337", 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	clientState, consensusState, header := newTestVerifiableHeader(t)
+	setConsensusState(clientStore, cdc, consensusState, header.TrustedHeight)
+	clientState.LatestHeight = header.GetHeight().Increment().(clienttypes.Height)
+
+	conflictingHeader := *header
+	conflictingSignedHeader := *header.SignedHeader
+	conflictingSignedHeader.AppHash = testAppHash
+	conflictingHeader.SignedHeader = &conflictingSignedHeader
+
+	// the evidence is verified rather than rejected for being below the latest height, the conflicting header
+	// is only rejected for not being proven by the test proof
+	err := clientState.VerifyClientMessage(ctx, cdc, clientStore, NewMisbehaviour("", header, &conflictingHeader))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNonMonotonicHeight)
+	require.Contains(t, err.Error(), "verifying Header_2 in Misbehaviour failed")
+}
+
+func TestUpdateCount(t *testing.T) {
+	ctx := newTestContext("union-devnet-1337", 10)
+	cdc := newTestCodec()
+	clientStore := newTestClientStore()
+
+	clientState, consensusState, header := newTestVerifiableHeader(t)
+	require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+	require.Zero(t, GetUpdateCount(clientStore))
+
+	// dry runs and failed verifications are not counted
+	_, _, err := CheckHeaderAndUpdateStateDryRun(ctx, cdc, clientStore, clientState, header)
+	require.NoError(t, err)
+	require.Zero(t, GetUpdateCount(clientStore))
+
+	appHash := header.SignedHeader.AppHash
+	header.SignedHeader.AppHash = testAppHash
+	require.Error(t, clientState.VerifyClientMessage(ctx, cdc, clientStore, header))
+	require.Zero(t, GetUpdateCount(clientStore))
+	header.SignedHeader.AppHash = appHash
+
+	require.NoError(t, clientState.VerifyClientMessage(ctx, cdc, clientStore, header))
+	clientState.UpdateState(ctx, cdc, clientStore, header)
+	require.Equal(t, uint64(1), GetUpdateCount(clientStore))
+
+	// duplicate headers are a no-op
+	clientState = getTestClientState(t, clientStore, cdc)
+	clientState.UpdateState(ctx, cdc, clientStore, header)
+	require.Equal(t, uint64(1), GetUpdateCount(clientStore))
+
+	// headers freezing the client are not counted
+	conflictingHeader, conflictingSignedHeader := *header, *header.SignedHeader
+	conflictingSignedHeader.AppHash = testAppHash
+	conflictingHeader.SignedHeader = &conflictingSignedHeader
+	clientState.UpdateState(ctx, cdc, clientStore, &conflictingHeader)
+	require.False(t, getTestClientState(t, clientStore, cdc).FrozenHeight.IsZero())
+	require.Equal(t, uint64(1), GetUpdateCount(clientStore))
+}
+
+// wrongClientMessage is a client message of another light client type.
+type wrongClientMessage struct{}
+
+func (wrongClientMessage) Reset()               {}
+func (wrongClientMessage) String() string       { return "wrong" }
+func (wrongClientMessage) ProtoMessage()        {}
+func (wrongClientMessage) ClientType() string   { return "07-tendermint" }
+func (wrongClientMessage) ValidateBasic() error { return nil }
+
+func TestMalformedClientMessage(t *testing.T) {
+	testCases := []struct {
+		name      string
+		clientMsg exported.ClientMessage
+		expErr    error
+	}{
+		{"nil client message", nil, clienttypes.ErrInvalidClientType},
+		{"nil header", (*Header)(nil), clienttypes.ErrInvalidClientType},
+		{"nil misbehaviour", (*Misbehaviour)(nil), clienttypes.ErrInvalidClientType},
+		{"wrong client message type", &wrongClientMessage{}, clienttypes.ErrInvalidClientType},
+		{"header without signed header", &Header{TrustedHeight: &clienttypes.Height{RevisionNumber: 1, RevisionHeight: 5}}, ErrInvalidHeader},
+		{"header without trusted height", &Header{SignedHeader: &LightHeader{Height: 6}}, ErrInvalidHeader},
+		{"misbehaviour without headers", &Misbehaviour{}, ErrInvalidHeader},
+		{"misbehaviour with a malformed header", &Misbehaviour{Header_1: &Header{}, Header_2: newTestHeader(6, 5, time.Unix(1, 0))}, ErrInvalidHeader},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(testChainID, 10)
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			consensusState, err := NewConsensusState(uint64(ctx.BlockTime().UnixNano()), commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+			snapshot := snapshotStore(clientStore)
+
+			require.NotPanics(t, func() {
+				err = clientState.VerifyClientMessage(ctx, cdc, clientStore, tc.clientMsg)
+				require.ErrorIs(t, err, tc.expErr)
+
+				require.False(t, clientState.CheckForMisbehaviour(ctx, cdc, clientStore, tc.clientMsg))
+				require.Empty(t, clientState.UpdateState(ctx, cdc, clientStore, tc.clientMsg))
+			})
+			require.Equal(t, snapshot, snapshotStore(clientStore))
+
+			if header, ok := tc.clientMsg.(*Header); ok {
+				require.NotPanics(t, func() {
+					_, _, err = CheckHeaderAndUpdateStateDryRun(ctx, cdc, clientStore, clientState, header)
+					require.ErrorIs(t, err, tc.expErr)
+				})
+			}
+		})
+	}
+}
+
+func TestUpdateStateTimeMonotonicity(t *testing.T) {
+	ctx := newTestContext(testChainID, 10)
+
+	testCases := []struct {
+		name      string
+		height    int64
+		timestamp time.Time
+		expFrozen bool
+	}{
+		{"increasing timestamp", 6, ctx.BlockTime(), false},
+		{"equal timestamp", 6, time.Unix(0, 100), true},
+		{"decreasing timestamp", 6, time.Unix(0, 99), true},
+		{"back-filled consensus state", 3, time.Unix(0, 50), false},
+		{"back-filled consensus state equal to the next", 3, time.Unix(0, 100), true},
+		{"back-filled consensus state after the next", 3, time.Unix(0, 101), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			clientState.TrustingPeriod = uint64(ctx.BlockTime().UnixNano())
+			clientState.UnbondingPeriod = clientState.TrustingPeriod + 1
+			consensusState, err := NewConsensusState(100, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+			header := newTestHeader(tc.height, 2, tc.timestamp)
+			expClientState, _, _, err := clientState.checkHeaderAndUpdateState(cdc, clientStore, header)
+			require.NoError(t, err)
+			clientState.UpdateState(ctx, cdc, clientStore, header)
+
+			// the update persists the client state of its dry run
+			updatedClientState := getTestClientState(t, clientStore, cdc)
+			require.Equal(t, expClientState, updatedClientState)
+			require.Equal(t, tc.expFrozen, !updatedClientState.FrozenHeight.IsZero())
+
+			_, found := GetConsensusState(clientStore, cdc, header.GetHeight())
+			require.Equal(t, !tc.expFrozen, found)
+		})
+	}
+}
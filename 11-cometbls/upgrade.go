@@ -1,16 +1,29 @@
 package cometbls
 
 import (
+	"fmt"
+
 	errorsmod "cosmossdk.io/errors"
 	storetypes "cosmossdk.io/store/types"
+	upgradetypes "cosmossdk.io/x/upgrade/types"
 
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
 
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	ibcerrors "github.com/cosmos/ibc-go/v8/modules/core/errors"
 	"github.com/cosmos/ibc-go/v8/modules/core/exported"
 )
 
+// UpgradePath is the path under which the counterparty x/upgrade module commits to the upgraded client
+// and consensus states. The client state does not carry a custom upgrade path, the default one is always used.
+var UpgradePath = []string{upgradetypes.StoreKey, upgradetypes.KeyUpgradedIBCState}
+
+// UpgradedRevisionStartHeight is the height a counterparty chain restarting from a new genesis at a new revision
+// starts at. Chains upgraded in place to a new revision keep counting heights from the upgrade height instead.
+const UpgradedRevisionStartHeight = 1
+
 // VerifyUpgradeAndUpdateState checks if the upgraded client has been committed by the current client
 // It will zero out all client-specific fields (e.g. TrustingPeriod) and verify all data
 // in client state that must be the same across all valid Tendermint clients for the new chain.
@@ -18,8 +31,11 @@ import (
 // - the upgradedClient is not a Tendermint ClientState
 // - the latest height of the client state does not have the same revision number or has a greater
 // height than the committed client.
+//   - the revision of upgraded client is lower than that of current client
 //   - the height of upgraded client is not greater than that of current client
 //   - the latest height of the new client does not match or is greater than the height in committed client
+//   - the height of upgraded client at a new revision is not contiguous with that of current client, see
+//     checkUpgradeHeightContinuity
 //   - any Tendermint chain specified parameter in upgraded client such as ChainID, UnbondingPeriod,
 //     and ProofSpecs do not match parameters set by committed client
 func (cs ClientState) VerifyUpgradeAndUpdateState(
@@ -27,5 +43,124 @@ func (cs ClientState) VerifyUpgradeAndUpdateState(
 	upgradedClient exported.ClientState, upgradedConsState exported.ConsensusState,
 	upgradeClientProof, upgradeConsStateProof []byte,
 ) error {
-	return errorsmod.Wrap(clienttypes.ErrInvalidUpgradeClient, "upgrade is not supported at the moment")
+	// last height of current counterparty chain must be client's latest height
+	lastHeight := cs.GetLatestHeight()
+
+	// upgraded client state and consensus state must be IBC cometbls client state and consensus state
+	// counterparty must also commit to the upgraded consensus state at a sub-path under the upgrade path
+	cometblsUpgradeClient, ok := upgradedClient.(*ClientState)
+	if !ok {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidClientType, "upgraded client must be CometBLS client. expected: %T got: %T",
+			&ClientState{}, upgradedClient)
+	}
+	cometblsUpgradeConsState, ok := upgradedConsState.(*ConsensusState)
+	if !ok {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "upgraded consensus state must be CometBLS consensus state. expected %T, got: %T",
+			&ConsensusState{}, upgradedConsState)
+	}
+
+	if cometblsUpgradeClient.GetLatestHeight().GetRevisionNumber() < lastHeight.GetRevisionNumber() {
+		return errorsmod.Wrapf(ibcerrors.ErrInvalidHeight, "upgraded client revision %d cannot be lower than current client revision %d",
+			cometblsUpgradeClient.GetLatestHeight().GetRevisionNumber(), lastHeight.GetRevisionNumber())
+	}
+
+	if !cometblsUpgradeClient.GetLatestHeight().GT(lastHeight) {
+		return errorsmod.Wrapf(ibcerrors.ErrInvalidHeight, "upgraded client height %s must be at greater than current client height %s",
+			cometblsUpgradeClient.LatestHeight, lastHeight)
+	}
+
+	if err := checkUpgradeHeightContinuity(cs.LatestHeight, cometblsUpgradeClient.LatestHeight); err != nil {
+		return err
+	}
+
+	// Must prove against latest consensus state to ensure we are verifying against latest upgrade plan
+	// This verifies that upgrade is intended for the provided revision, since committed client must exist
+	// at this consensus state
+	consState, found := GetConsensusState(clientStore, cdc, lastHeight)
+	if !found {
+		return errorsmod.Wrap(ErrConsensusStateNotFound, "could not retrieve consensus state for lastHeight")
+	}
+
+	// Verify client proof
+	bz, err := cdc.MarshalInterface(cometblsUpgradeClient.ZeroCustomFields())
+	if err != nil {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidClient, "could not marshal client state: %v", err)
+	}
+	upgradeClientPath := constructUpgradeMerklePath(UpgradePath, lastHeight, upgradetypes.KeyUpgradedClient)
+	if err := cs.VerifyMembershipAtRoot(consState.Root, upgradeClientPath, upgradeClientProof, bz); err != nil {
+		return errorsmod.Wrapf(err, "client state proof failed. Path: %s", upgradeClientPath.GetKeyPath())
+	}
+
+	// Verify consensus state proof
+	bz, err = cdc.MarshalInterface(cometblsUpgradeConsState)
+	if err != nil {
+		return errorsmod.Wrapf(clienttypes.ErrInvalidConsensus, "could not marshal consensus state: %v", err)
+	}
+	upgradeConsStatePath := constructUpgradeMerklePath(UpgradePath, lastHeight, upgradetypes.KeyUpgradedConsState)
+	if err := cs.VerifyMembershipAtRoot(consState.Root, upgradeConsStatePath, upgradeConsStateProof, bz); err != nil {
+		return errorsmod.Wrapf(err, "consensus state proof failed. Path: %s", upgradeConsStatePath.GetKeyPath())
+	}
+
+	// Construct new client state and consensus state
+	// Relayer chosen client parameters are ignored.
+	// All chain-chosen parameters come from committed client, all client-chosen parameters
+	// come from current client.
+	newClientState := NewClientState(
+		cometblsUpgradeClient.ChainId, cs.TrustingPeriod, cometblsUpgradeClient.UnbondingPeriod,
+		cs.MaxClockDrift, cometblsUpgradeClient.LatestHeight,
+	)
+
+	if err := newClientState.Validate(); err != nil {
+		return errorsmod.Wrap(err, "updated client state failed basic validation")
+	}
+
+	// The new consensus state is merely used as a trusted kernel against which headers on the new
+	// chain can be verified. The root is just a stand-in sentinel value as it cannot be known in advance, thus no proof verification will pass.
+	// NOTE: We do not set processed time for this consensus state since this consensus state should not be used for packet verification
+	// as the root is empty. The next consensus state submitted using update will be usable for packet-verification.
+	newConsState, err := NewConsensusState(
+		cometblsUpgradeConsState.Timestamp, commitmenttypes.NewMerkleRoot([]byte(SentinelRoot)), cometblsUpgradeConsState.NextValidatorsHash,
+	)
+	if err != nil {
+		return errorsmod.Wrap(err, "upgraded consensus state failed basic validation")
+	}
+
+	setClientState(clientStore, cdc, newClientState)
+	setConsensusState(clientStore, cdc, newConsState, newClientState.LatestHeight)
+	setConsensusMetadata(ctx, clientStore, newClientState.LatestHeight)
+
+	return nil
+}
+
+// checkUpgradeHeightContinuity returns an ErrInvalidHeight if an upgrade bumping the revision skips or overlaps heights
+// across the revision boundary, the current client latest height being the last height of the previous revision. At a
+// new revision the chain either restarts from UpgradedRevisionStartHeight or keeps counting heights from the last
+// height. Upgrades within the same revision are not checked, the upgraded height only has to be greater.
+func checkUpgradeHeightContinuity(lastHeight, upgradedHeight clienttypes.Height) error {
+	if upgradedHeight.RevisionNumber == lastHeight.RevisionNumber {
+		return nil
+	}
+
+	nextHeight := lastHeight.RevisionHeight + 1
+	if upgradedHeight.RevisionHeight != UpgradedRevisionStartHeight && upgradedHeight.RevisionHeight != nextHeight {
+		return errorsmod.Wrapf(ibcerrors.ErrInvalidHeight, "upgraded client height %s must start its revision at height %d or %d following current client height %s",
+			upgradedHeight, UpgradedRevisionStartHeight, nextHeight, lastHeight)
+	}
+	return nil
+}
+
+// construct MerklePath for the committed client or consensus state from upgradePath,
+// key being either upgradedClient or upgradedConsState
+func constructUpgradeMerklePath(upgradePath []string, lastHeight exported.Height, key string) commitmenttypes.MerklePath {
+	// copy all elements from upgradePath except final element
+	path := make([]string, len(upgradePath)-1)
+	copy(path, upgradePath)
+
+	// append lastHeight and key to last key of upgradePath and use as lastKey of path
+	// this will create the IAVL key that is used to store the state in upgrade store
+	lastKey := upgradePath[len(upgradePath)-1]
+	appendedKey := fmt.Sprintf("%s/%d/%s", lastKey, lastHeight.GetRevisionHeight(), key)
+
+	path = append(path, appendedKey)
+	return commitmenttypes.NewMerklePath(path...)
 }
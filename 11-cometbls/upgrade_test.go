@@ -0,0 +1,204 @@
+package cometbls
+
+import (
+	"fmt"
+	"testing"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/store/metrics"
+	"cosmossdk.io/store/rootmulti"
+	storetypes "cosmossdk.io/store/types"
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/codec"
+	clienttypes "github.com/cosmos/ibc-go/v8/modules/core/02-client/types"
+	commitmenttypes "github.com/cosmos/ibc-go/v8/modules/core/23-commitment/types"
+	ibcerrors "github.com/cosmos/ibc-go/v8/modules/core/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUpgradeProofs commits the upgraded client and consensus states in an IAVL upgrade store the same way the
+// x/upgrade module does and returns the resulting commitment root along with an ICS 23 proof of each state.
+func newTestUpgradeProofs(
+	t *testing.T, cdc codec.BinaryCodec, lastHeight clienttypes.Height,
+	upgradedClient *ClientState, upgradedConsState *ConsensusState,
+) (commitmenttypes.MerkleRoot, []byte, []byte) {
+	t.Helper()
+
+	store := rootmulti.NewStore(dbm.NewMemDB(), log.NewNopLogger(), metrics.NewNoOpMetrics())
+	storeKey := storetypes.NewKVStoreKey(upgradetypes.StoreKey)
+	store.MountStoreWithDB(storeKey, storetypes.StoreTypeIAVL, nil)
+	require.NoError(t, store.LoadVersion(0))
+
+	clientKey := []byte(fmt.Sprintf("%s/%d/%s", upgradetypes.KeyUpgradedIBCState, lastHeight.RevisionHeight, upgradetypes.KeyUpgradedClient))
+	consStateKey := []byte(fmt.Sprintf("%s/%d/%s", upgradetypes.KeyUpgradedIBCState, lastHeight.RevisionHeight, upgradetypes.KeyUpgradedConsState))
+
+	clientBz, err := cdc.MarshalInterface(upgradedClient.ZeroCustomFields())
+	require.NoError(t, err)
+	consStateBz, err := cdc.MarshalInterface(upgradedConsState)
+	require.NoError(t, err)
+
+	store.GetCommitKVStore(storeKey).Set(clientKey, clientBz)
+	store.GetCommitKVStore(storeKey).Set(consStateKey, consStateBz)
+	cid := store.Commit()
+
+	proof := func(key []byte) []byte {
+		res, err := store.Query(&storetypes.RequestQuery{
+			Path:  fmt.Sprintf("/%s/key", upgradetypes.StoreKey),
+			Data:  key,
+			Prove: true,
+		})
+		require.NoError(t, err)
+
+		merkleProof, err := commitmenttypes.ConvertProofs(res.ProofOps)
+		require.NoError(t, err)
+
+		bz, err := merkleProof.Marshal()
+		require.NoError(t, err)
+		return bz
+	}
+
+	return commitmenttypes.NewMerkleRoot(cid.Hash), proof(clientKey), proof(consStateKey)
+}
+
+func TestVerifyUpgradeAndUpdateState(t *testing.T) {
+	testCases := []struct {
+		name         string
+		latestHeight clienttypes.Height
+		malleate     func(clientProof, consStateProof []byte) ([]byte, []byte)
+		expErr       error
+	}{
+		{
+			"successful upgrade to the next revision",
+			clienttypes.NewHeight(2, 1),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			nil,
+		},
+		{
+			"upgrade to the next revision keeping heights",
+			clienttypes.NewHeight(2, 6),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			nil,
+		},
+		{
+			"upgrade within the same revision",
+			clienttypes.NewHeight(1, 6),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			nil,
+		},
+		{
+			"upgrade to the next revision skipping heights",
+			clienttypes.NewHeight(2, 7),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			ibcerrors.ErrInvalidHeight,
+		},
+		{
+			"upgrade to the next revision overlapping heights",
+			clienttypes.NewHeight(2, 3),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			ibcerrors.ErrInvalidHeight,
+		},
+		{
+			"upgrade within the same revision skipping heights",
+			clienttypes.NewHeight(1, 7),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			nil,
+		},
+		{
+			"upgrade to a lower revision",
+			clienttypes.NewHeight(0, 10),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			ibcerrors.ErrInvalidHeight,
+		},
+		{
+			"upgrade at the same height",
+			clienttypes.NewHeight(1, 5),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return clientProof, consStateProof },
+			ibcerrors.ErrInvalidHeight,
+		},
+		{
+			"swapped proofs",
+			clienttypes.NewHeight(2, 1),
+			func(clientProof, consStateProof []byte) ([]byte, []byte) { return consStateProof, clientProof },
+			ErrProofValueMismatch,
+		},
+		{
+			"malformed client proof",
+			clienttypes.NewHeight(2, 1),
+			func(_, consStateProof []byte) ([]byte, []byte) { return []byte("malformed"), consStateProof },
+			ErrMalformedProof,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(testChainID, 10)
+			cdc := newTestCodec()
+			clientStore := newTestClientStore()
+
+			clientState := newTestClientState()
+			upgradedChainID := fmt.Sprintf("union-devnet-%d", tc.latestHeight.RevisionNumber)
+			upgradedClient := NewClientState(upgradedChainID, 0, clientState.UnbondingPeriod, 0, tc.latestHeight)
+			upgradedConsState, err := NewConsensusState(2, commitmenttypes.NewMerkleRoot(testAppHash), testNextValidatorsHash)
+			require.NoError(t, err)
+
+			root, clientProof, consStateProof := newTestUpgradeProofs(t, cdc, clientState.LatestHeight, upgradedClient, upgradedConsState)
+			consensusState, err := NewConsensusState(1, root, testNextValidatorsHash)
+			require.NoError(t, err)
+			require.NoError(t, clientState.Initialize(ctx, cdc, clientStore, consensusState))
+
+			clientProof, consStateProof = tc.malleate(clientProof, consStateProof)
+			err = clientState.VerifyUpgradeAndUpdateState(ctx, cdc, clientStore, upgradedClient, upgradedConsState, clientProof, consStateProof)
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				require.True(t, clientState.Equal(getTestClientState(t, clientStore, cdc)))
+				return
+			}
+
+			require.NoError(t, err)
+
+			newClientState := getTestClientState(t, clientStore, cdc)
+			require.Equal(t, upgradedClient.ChainId, newClientState.ChainId)
+			require.Equal(t, tc.latestHeight, newClientState.LatestHeight)
+			require.Equal(t, clientState.TrustingPeriod, newClientState.TrustingPeriod)
+			require.Equal(t, clientState.MaxClockDrift, newClientState.MaxClockDrift)
+
+			newConsState, found := GetConsensusState(clientStore, cdc, tc.latestHeight)
+			require.True(t, found)
+			require.Equal(t, upgradedConsState.Timestamp, newConsState.Timestamp)
+			require.Equal(t, upgradedConsState.NextValidatorsHash, newConsState.NextValidatorsHash)
+			require.Equal(t, []byte(SentinelRoot), newConsState.Root.Hash)
+		})
+	}
+}
+
+func TestCheckUpgradeHeightContinuity(t *testing.T) {
+	lastHeight := clienttypes.NewHeight(1, 100)
+
+	testCases := []struct {
+		name           string
+		upgradedHeight clienttypes.Height
+		expPass        bool
+	}{
+		{"new revision restarting heights", clienttypes.NewHeight(2, UpgradedRevisionStartHeight), true},
+		{"new revision keeping heights", clienttypes.NewHeight(2, 101), true},
+		{"skipped revision restarting heights", clienttypes.NewHeight(3, UpgradedRevisionStartHeight), true},
+		{"same revision next height", clienttypes.NewHeight(1, 101), true},
+		{"new revision gapped heights", clienttypes.NewHeight(2, 102), false},
+		{"new revision overlapping heights", clienttypes.NewHeight(2, 100), false},
+		{"new revision starting at zero", clienttypes.NewHeight(2, 0), false},
+		// same revision upgrades are only required to be greater by VerifyUpgradeAndUpdateState
+		{"same revision gapped heights", clienttypes.NewHeight(1, 102), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkUpgradeHeightContinuity(lastHeight, tc.upgradedHeight)
+			if tc.expPass {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ibcerrors.ErrInvalidHeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package cometbls
+
+import (
+	"math/big"
+
+	errorsmod "cosmossdk.io/errors"
+	sdkmath "cosmossdk.io/math"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Bn254PubKeyTypeURL is the type URL of the BN254 consensus public keys of CometBLS validators.
+const Bn254PubKeyTypeURL = "/cosmos.crypto.bn254.PubKey"
+
+// MaxValidators is the maximum number of validators the CometBLS light client circuit can verify a commit of.
+const MaxValidators = 128
+
+// Merkle tree node prefixes of the CometBLS validator set hash.
+const (
+	validatorLeafPrefix  = 0
+	validatorInnerPrefix = 1
+)
+
+// hashableBit is the bit of the public key coordinates moved out of their hashable part, the coordinates of the base
+// field being wider than the scalar field MiMC operates on.
+const hashableBit = 253
+
+// validatorSetHash returns the CometBLS hash of the validator set made of the given staking validators, in the given
+// order, which is the order the staking module stores the historical info validator set in. The consensus power of
+// the validators is computed with the given power reduction.
+//
+// CometBLS does not commit to the SHA-256 merkle root of the encoded validators as CometBFT does, but to the MiMC
+// merkle root of the validators leaves as computed by the light client circuit, see validatorLeafHash.
+func validatorSetHash(valSet []stakingtypes.Validator, powerReduction sdkmath.Int) ([]byte, error) {
+	if len(valSet) > MaxValidators {
+		return nil, errorsmod.Wrapf(ErrInvalidValidatorSet, "validator set cannot have more than %d validators, got: %d", MaxValidators, len(valSet))
+	}
+
+	leaves := make([]fr.Element, len(valSet))
+	for i, v := range valSet {
+		power := v.ConsensusPower(powerReduction)
+		if power <= 0 {
+			return nil, errorsmod.Wrapf(ErrInsufficientVotingPower, "validator %s must have a positive consensus power, got: %d", v.OperatorAddress, power)
+		}
+
+		pubkey, err := validatorPublicKey(v)
+		if err != nil {
+			return nil, errorsmod.Wrapf(err, "validator %s", v.OperatorAddress)
+		}
+
+		leaves[i] = validatorLeafHash(pubkey, power)
+	}
+
+	root := mimcMerkleRoot(leaves)
+	return root.Marshal(), nil
+}
+
+// validatorPublicKey decodes the BN254 consensus public key of the validator. The key is decoded from the raw
+// protobuf encoding of the consensus public key as the BN254 key type is not known to the interface registry of
+// the vanilla SDK.
+func validatorPublicKey(v stakingtypes.Validator) (curve.G1Affine, error) {
+	if v.ConsensusPubkey == nil {
+		return curve.G1Affine{}, errorsmod.Wrap(ErrInvalidPublicKey, "consensus public key cannot be empty")
+	}
+	if v.ConsensusPubkey.TypeUrl != Bn254PubKeyTypeURL {
+		return curve.G1Affine{}, errorsmod.Wrapf(ErrInvalidPublicKey, "consensus public key must be a %s, got: %s", Bn254PubKeyTypeURL, v.ConsensusPubkey.TypeUrl)
+	}
+
+	// the key is the only field of the PubKey message
+	var key []byte
+	b := v.ConsensusPubkey.Value
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return curve.G1Affine{}, errorsmod.Wrapf(ErrInvalidPublicKey, "malformed consensus public key: %s", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			key, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return curve.G1Affine{}, errorsmod.Wrapf(ErrInvalidPublicKey, "malformed consensus public key: %s", protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+
+	if len(key) != curve.SizeOfG1AffineCompressed {
+		return curve.G1Affine{}, errorsmod.Wrapf(ErrInvalidPublicKey, "consensus public key must be %d bytes, got: %d", curve.SizeOfG1AffineCompressed, len(key))
+	}
+
+	var pubkey curve.G1Affine
+	if _, err := pubkey.SetBytes(key); err != nil {
+		return curve.G1Affine{}, errorsmod.Wrap(ErrInvalidPublicKey, err.Error())
+	}
+	if pubkey.IsInfinity() {
+		return curve.G1Affine{}, errorsmod.Wrap(ErrInvalidPublicKey, "consensus public key cannot be the point at infinity")
+	}
+
+	return pubkey, nil
+}
+
+// validatorLeafHash returns the merkle leaf hash of the validator with the given public key and consensus power.
+// The leaf is the MiMC hash of the public key coordinates, split into their hashable part and their most significant
+// bit, and of the power.
+func validatorLeafHash(pubkey curve.G1Affine, power int64) fr.Element {
+	hashableX, msbX := splitCoordinate(pubkey.X.BigInt(new(big.Int)))
+	hashableY, msbY := splitCoordinate(pubkey.Y.BigInt(new(big.Int)))
+
+	var leafPower fr.Element
+	leafPower.SetUint64(uint64(power))
+
+	leaf := mimcHash(hashableX, hashableY, msbX, msbY, leafPower)
+	return mimcHash(fr.NewElement(validatorLeafPrefix), leaf)
+}
+
+// splitCoordinate splits a public key coordinate into its value with the hashable bit cleared and the hashable bit.
+func splitCoordinate(coordinate *big.Int) (hashable fr.Element, msb fr.Element) {
+	msb.SetUint64(uint64(coordinate.Bit(hashableBit)))
+	hashable.SetBigInt(coordinate.SetBit(coordinate, hashableBit, 0))
+	return hashable, msb
+}
+
+// mimcMerkleRoot returns the MiMC merkle root of the leaf hashes, splitting the leaves at the largest power of two
+// smaller than their number. An empty tree hashes to the MiMC hash of nothing.
+func mimcMerkleRoot(leaves []fr.Element) fr.Element {
+	switch len(leaves) {
+	case 0:
+		return mimcHash()
+	case 1:
+		return leaves[0]
+	}
+
+	split := 1
+	for split*2 < len(leaves) {
+		split *= 2
+	}
+	return mimcHash(fr.NewElement(validatorInnerPrefix), mimcMerkleRoot(leaves[:split]), mimcMerkleRoot(leaves[split:]))
+}
+
+// mimcHash returns the MiMC hash of the field elements.
+func mimcHash(elems ...fr.Element) fr.Element {
+	h := mimc.NewMiMC()
+	for _, elem := range elems {
+		// canonical field elements are always accepted
+		b := elem.Bytes()
+		_, _ = h.Write(b[:])
+	}
+
+	var sum fr.Element
+	sum.SetBytes(h.Sum(nil))
+	return sum
+}
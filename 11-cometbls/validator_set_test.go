@@ -0,0 +1,103 @@
+package cometbls
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorSetHash(t *testing.T) {
+	// genesis validators of union-testnet-9, each bonded with 1000000000000 muno
+	keys := []string{
+		"d2a41c04fd486e00c5f0d9367310aece327ec39495a74ebf914eb7b6b566c89d",
+		"d6b8818ae0d736fd9c4d656e75fc9352454a7410829ef2d3dfe8e4d46c6c6936",
+		"ad8af9d146cc8dcbd6cb6fd8e5d8bdaaf96ce89594e309f8f0ef798bb80fa24b",
+		"9f7af7bff96837df2b5141e3d8fa5992f6e96862e3910dfdcd5dbf73343f07c7",
+		"9e9088f1225afdd03bb4101c56ba457405247b2f9fef08a93d64801c2b1343bf",
+	}
+	valSet := make([]stakingtypes.Validator, len(keys))
+	for i, key := range keys {
+		bz, err := hex.DecodeString(key)
+		require.NoError(t, err)
+		valSet[i] = stakingtypes.Validator{
+			ConsensusPubkey: newTestBn254PubKey(bz),
+			Status:          stakingtypes.Bonded,
+			Tokens:          sdkmath.NewInt(1_000_000_000_000),
+		}
+	}
+
+	// the root the light client circuit computes over these validators, in this order
+	expHash, err := hex.DecodeString("1b8dee17e3ebae294c024a6af0cd41a701565373660f3c50c6ddeaf83181956e")
+	require.NoError(t, err)
+
+	valSetHash, err := validatorSetHash(valSet, sdk.DefaultPowerReduction)
+	require.NoError(t, err)
+	require.Equal(t, expHash, valSetHash)
+
+	// the consensus powers are committed to
+	valSetHash, err = validatorSetHash(valSet, sdk.DefaultPowerReduction.QuoRaw(2))
+	require.NoError(t, err)
+	require.NotEqual(t, expHash, valSetHash)
+}
+
+func TestValidatorSetHashInvalid(t *testing.T) {
+	validator := newTestValidator(1, sdk.DefaultPowerReduction)
+	key := validator.ConsensusPubkey.Value[2:]
+
+	tooMany := make([]stakingtypes.Validator, MaxValidators+1)
+	for i := range tooMany {
+		tooMany[i] = validator
+	}
+
+	testCases := []struct {
+		name     string
+		malleate func(v *stakingtypes.Validator)
+		valSet   []stakingtypes.Validator
+		expErr   error
+	}{
+		{"zero power", func(v *stakingtypes.Validator) { v.Tokens = sdkmath.ZeroInt() }, nil, ErrInsufficientVotingPower},
+		{"power below the power reduction", func(v *stakingtypes.Validator) { v.Tokens = sdk.DefaultPowerReduction.SubRaw(1) }, nil, ErrInsufficientVotingPower},
+		{"no public key", func(v *stakingtypes.Validator) { v.ConsensusPubkey = nil }, nil, ErrInvalidPublicKey},
+		{"ed25519 public key", func(v *stakingtypes.Validator) {
+			v.ConsensusPubkey = &codectypes.Any{TypeUrl: "/cosmos.crypto.ed25519.PubKey", Value: v.ConsensusPubkey.Value}
+		}, nil, ErrInvalidPublicKey},
+		{"malformed public key", func(v *stakingtypes.Validator) { v.ConsensusPubkey.Value = v.ConsensusPubkey.Value[:10] }, nil, ErrInvalidPublicKey},
+		{"truncated public key", func(v *stakingtypes.Validator) { v.ConsensusPubkey = newTestBn254PubKey(key[:31]) }, nil, ErrInvalidPublicKey},
+		{"public key out of the field", func(v *stakingtypes.Validator) {
+			outOfField := bytes.Repeat([]byte{0xff}, len(key))
+			outOfField[0] = 0xbf
+			v.ConsensusPubkey = newTestBn254PubKey(outOfField)
+		}, nil, ErrInvalidPublicKey},
+		{"point at infinity", func(v *stakingtypes.Validator) {
+			infinity := make([]byte, len(key))
+			infinity[0] = 0x40
+			v.ConsensusPubkey = newTestBn254PubKey(infinity)
+		}, nil, ErrInvalidPublicKey},
+		{"too many validators", nil, tooMany, ErrInvalidValidatorSet},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			valSet := tc.valSet
+			if tc.malleate != nil {
+				v := newTestValidator(1, sdk.DefaultPowerReduction)
+				tc.malleate(&v)
+				valSet = []stakingtypes.Validator{v}
+			}
+
+			_, err := validatorSetHash(valSet, sdk.DefaultPowerReduction)
+			require.ErrorIs(t, err, tc.expErr)
+		})
+	}
+
+	// the unknown fields of the public key are skipped
+	validator.ConsensusPubkey.Value = append([]byte{0x10, 0x01}, validator.ConsensusPubkey.Value...)
+	_, err := validatorSetHash([]stakingtypes.Validator{validator}, sdk.DefaultPowerReduction)
+	require.NoError(t, err)
+}
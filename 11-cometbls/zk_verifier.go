@@ -22,7 +22,10 @@ const (
 	FQ_SIZE         = 32
 	G1_SIZE         = 2 * FQ_SIZE
 	G2_SIZE         = 2 * G1_SIZE
+	ZKP_SIZE        = G1_SIZE + G2_SIZE + G1_SIZE + G1_SIZE + G1_SIZE
 	CometblsHMACKey = "CometBLS"
+	// MaxProverChainIDLen is the maximum length of the chain-id committed to by the proof public inputs
+	MaxProverChainIDLen = 31
 )
 
 var (
@@ -66,6 +69,9 @@ func init() {
 }
 
 func ParseZKP(data []byte) (*ZKP, error) {
+	if len(data) != ZKP_SIZE {
+		return nil, fmt.Errorf("invalid zkp size, expected: %d, got: %d", ZKP_SIZE, len(data))
+	}
 
 	zkp := ZKP{}
 
@@ -89,8 +95,8 @@ func ParseZKP(data []byte) (*ZKP, error) {
 }
 
 func (zkp ZKP) Verify(trustedValidatorsHash []byte, header ProverLightHeader) error {
-	if len(header.ChainId) > 31 {
-		return errors.New("chain id length cannot be larger than 31")
+	if len(header.ChainId) > MaxProverChainIDLen {
+		return fmt.Errorf("chain id length cannot be larger than %d", MaxProverChainIDLen)
 	}
 
 	commHash := commitmentsHash(zkp.ProofCommitment)
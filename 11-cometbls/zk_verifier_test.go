@@ -21,7 +21,7 @@ func TestVerifier(t *testing.T) {
 	err = zkp.Verify(
 
 		trustedValHash,
-		LightHeader{
+		ProverLightHeader{
 			ChainId:            "union-devnet-1337",
 			Height:             3405691582,
 			Time:               time.Unix(1710783278, 499600406),
@@ -33,3 +33,10 @@ func TestVerifier(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestParseZKPInvalidSize(t *testing.T) {
+	for _, size := range []int{0, G1_SIZE, ZKP_SIZE - 1, ZKP_SIZE + 1} {
+		_, err := ParseZKP(make([]byte, size))
+		assert.Error(t, err)
+	}
+}